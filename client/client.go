@@ -2,13 +2,32 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
 	"io"
+	"iter"
 	"log"
+	"os"
 	pb "routeguide/routeguide"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	serverAddr = flag.String("addr", "localhost:50051", "server address, e.g. host:port or unix:///path/to/socket")
+	useTLS     = flag.Bool("tls", false, "connect with TLS, verifying the server against the system roots unless -ca-file is set")
+	caFile     = flag.String("ca-file", "", "PEM file of CAs to verify the server certificate; implies -tls")
+	certFile   = flag.String("cert-file", "", "PEM client certificate for mutual TLS; implies -tls")
+	keyFile    = flag.String("key-file", "", "PEM private key for -cert-file")
+	serverName = flag.String("server-name", "", "name to verify the server certificate against instead of the host in -addr; implies -tls")
+	apiKey     = flag.String("api-key", "", "API key to send with every RPC in x-api-key metadata")
+	token      = flag.String("token", "", "JWT to send with every RPC as an authorization bearer token")
 )
 
 func getFeature(client pb.RouteGuideClient, ctx context.Context) {
@@ -25,11 +44,13 @@ func getFeature(client pb.RouteGuideClient, ctx context.Context) {
 
 	log.Printf("Feature name: %s, Latitude: %d, Longitude: %d",
 		feature.Name, feature.Location.Latitude, feature.Location.Longitude)
+	log.Printf("Category: %s, Elevation: %.0f m, Description: %s",
+		feature.Category, feature.ElevationM, feature.Description)
 }
 
 func listFeatures(client pb.RouteGuideClient, ctx context.Context) {
 	log.Println("=== ListFeatures ===")
-	rect := &pb.Rectangle{
+	rect := &pb.ListFeaturesRequest{
 		BottomLeftCorner: &pb.Point{
 			Latitude:  385000000,
 			Longitude: -780000000,
@@ -53,6 +74,196 @@ func listFeatures(client pb.RouteGuideClient, ctx context.Context) {
 		}
 		log.Printf("- %s at (%d, %d)", feature.Name, feature.Location.Latitude, feature.Location.Longitude)
 	}
+
+	// Same rectangle, keeping only monuments
+	rect.Filter = &pb.FeatureFilter{Categories: []pb.Category{pb.Category_CATEGORY_MONUMENT}}
+	stream, err = client.ListFeatures(ctx, rect)
+	if err != nil {
+		log.Fatalf("ListFeature failed: %v", err)
+	}
+
+	log.Println("Monuments in rectangle:")
+	for {
+		feature, err := stream.Recv()
+		if err != nil {
+			break // End of stream (EOF is normal)
+		}
+		log.Printf("- %s at (%d, %d)", feature.Name, feature.Location.Latitude, feature.Location.Longitude)
+	}
+}
+
+func listFeaturesPaged(client pb.RouteGuideClient, ctx context.Context) {
+	log.Println("=== ListFeatures (paged) ===")
+	req := &pb.ListFeaturesRequest{
+		BottomLeftCorner: &pb.Point{
+			Latitude:  385000000,
+			Longitude: -780000000,
+		},
+		TopRightCorner: &pb.Point{
+			Latitude:  410000000,
+			Longitude: -735000000,
+		},
+		PageSize: 2,
+		OrderBy:  pb.SortOrder_SORT_ORDER_NAME,
+	}
+
+	log.Println("Features in rectangle by name, fetched two at a time:")
+	for feature, err := range featurePages(client, ctx, req) {
+		if err != nil {
+			log.Fatalf("ListFeatures failed: %v", err)
+		}
+		log.Printf("- %s (id %s)", feature.Name, feature.Id)
+	}
+}
+
+// featurePages iterates over every feature ListFeatures returns for req,
+// requesting one page at a time and following each page's next-page-token
+// trailer. Iteration stops after yielding an error.
+func featurePages(client pb.RouteGuideClient, ctx context.Context, req *pb.ListFeaturesRequest) iter.Seq2[*pb.Feature, error] {
+	return func(yield func(*pb.Feature, error) bool) {
+		req := proto.Clone(req).(*pb.ListFeaturesRequest)
+		for {
+			token, more := fetchPage(client, ctx, req, yield)
+			if !more || token == "" {
+				return
+			}
+			req.PageToken = token
+		}
+	}
+}
+
+// fetchPage yields the features of one page and returns the token for the
+// next one. more is false if iteration should stop.
+func fetchPage(client pb.RouteGuideClient, ctx context.Context, req *pb.ListFeaturesRequest, yield func(*pb.Feature, error) bool) (token string, more bool) {
+	// Cancelling abandons the rest of the page if the caller stops early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.ListFeatures(ctx, req)
+	if err != nil {
+		yield(nil, err)
+		return "", false
+	}
+	for {
+		feature, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			yield(nil, err)
+			return "", false
+		}
+		if !yield(feature, nil) {
+			return "", false
+		}
+	}
+	if tokens := stream.Trailer().Get("next-page-token"); len(tokens) > 0 {
+		return tokens[0], true
+	}
+	return "", true
+}
+
+func listFeaturesInRadius(client pb.RouteGuideClient, ctx context.Context) {
+	log.Println("=== ListFeaturesInRadius ===")
+	circle := &pb.Circle{
+		Center:  &pb.Point{Latitude: 406000000, Longitude: -740000000},
+		RadiusM: 25000,
+	}
+
+	stream, err := client.ListFeaturesInRadius(ctx, circle)
+	if err != nil {
+		log.Fatalf("ListFeaturesInRadius failed: %v", err)
+	}
+
+	log.Printf("Features within %.0f metres of (%d, %d):",
+		circle.RadiusM, circle.Center.Latitude, circle.Center.Longitude)
+	for {
+		feature, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("ListFeaturesInRadius failed: %v", err)
+		}
+		log.Printf("- %s at (%d, %d)", feature.Name, feature.Location.Latitude, feature.Location.Longitude)
+	}
+}
+
+func listFeaturesInPolygon(client pb.RouteGuideClient, ctx context.Context) {
+	log.Println("=== ListFeaturesInPolygon ===")
+	// A triangle around Philadelphia and Manhattan
+	polygon := &pb.Polygon{
+		Points: []*pb.Point{
+			{Latitude: 390000000, Longitude: -760000000},
+			{Latitude: 410000000, Longitude: -745000000},
+			{Latitude: 400000000, Longitude: -730000000},
+		},
+	}
+
+	stream, err := client.ListFeaturesInPolygon(ctx, polygon)
+	if err != nil {
+		log.Fatalf("ListFeaturesInPolygon failed: %v", err)
+	}
+
+	log.Println("Features in polygon:")
+	for {
+		feature, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("ListFeaturesInPolygon failed: %v", err)
+		}
+		log.Printf("- %s at (%d, %d)", feature.Name, feature.Location.Latitude, feature.Location.Longitude)
+	}
+}
+
+func nearestFeatures(client pb.RouteGuideClient, ctx context.Context) {
+	log.Println("=== NearestFeatures ===")
+	req := &pb.NearestRequest{
+		Point: &pb.Point{Latitude: 400000000, Longitude: -750000000},
+		K:     3,
+	}
+
+	stream, err := client.NearestFeatures(ctx, req)
+	if err != nil {
+		log.Fatalf("NearestFeatures failed: %v", err)
+	}
+
+	log.Printf("Closest %d features to (%d, %d):", req.K, req.Point.Latitude, req.Point.Longitude)
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("NearestFeatures failed: %v", err)
+		}
+		log.Printf("- %s at (%d, %d), %.0f metres away", result.Feature.Name,
+			result.Feature.Location.Latitude, result.Feature.Location.Longitude, result.DistanceM)
+	}
+}
+
+func searchFeatures(client pb.RouteGuideClient, ctx context.Context) {
+	log.Println("=== SearchFeatures ===")
+	req := &pb.SearchRequest{Query: "liber", Limit: 5}
+
+	stream, err := client.SearchFeatures(ctx, req)
+	if err != nil {
+		log.Fatalf("SearchFeatures failed: %v", err)
+	}
+
+	log.Printf("Features matching %q:", req.Query)
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("SearchFeatures failed: %v", err)
+		}
+		log.Printf("- %s (score %.2f)", result.Feature.Name, result.Score)
+	}
 }
 
 func recordRoute(client pb.RouteGuideClient, ctx context.Context) {
@@ -79,8 +290,33 @@ func recordRoute(client pb.RouteGuideClient, ctx context.Context) {
 	if err != nil {
 		log.Fatalf("Failed to receive route summary: %v", err)
 	}
-	log.Printf("Route summary: %d points, %d features",
-		summary.PointCount, summary.FeatureCount)
+	log.Printf("Route summary: %d points, %d features, %d metres, %d seconds",
+		summary.PointCount, summary.FeatureCount, summary.Distance, summary.ElapsedTime)
+}
+
+// printNotes logs every note received on stream and closes the returned
+// channel once the server ends the stream
+func printNotes(stream pb.RouteGuide_RouteChatClient, who string) chan struct{} {
+	waitc := make(chan struct{})
+	go func() {
+		for {
+			input, err := stream.Recv()
+			if err == io.EOF {
+				close(waitc)
+				return
+			}
+			if err != nil {
+				log.Fatalf("client.RouteChat failed: %v", err)
+			}
+			from := ""
+			if input.Author != "" {
+				from = " from " + input.Author
+			}
+			log.Printf("%s received message #%d%s: %v at location: (%d, %d)", who,
+				input.Sequence, from, input.Message, input.Location.Latitude, input.Location.Longitude)
+		}
+	}()
+	return waitc
 }
 
 func routeChat(client pb.RouteGuideClient, ctx context.Context) {
@@ -94,26 +330,24 @@ func routeChat(client pb.RouteGuideClient, ctx context.Context) {
 		{Location: &pb.Point{Latitude: 0, Longitude: 3}, Message: "Sixth message"},
 	}
 
-	stream, err := client.RouteChat(ctx)
+	// A second participant joins the same locations without posting, so it
+	// receives each location's history once and then every new note
+	listener, err := client.RouteChat(ctx)
 	if err != nil {
 		log.Fatalf("client.RouteChat failed: %v", err)
 	}
-
-	waitc := make(chan struct{})
-	go func() {
-		for {
-			input, err := stream.Recv()
-			if err == io.EOF {
-				close(waitc)
-				return
-			}
-			if err != nil {
-				log.Fatalf("client.RouteChat failed: %v", err)
-			}
-			log.Printf("Received message: %v at location: (%d, %d)",
-				input.Message, input.Location.Latitude, input.Location.Longitude)
+	for _, note := range notes[:3] {
+		if err := listener.Send(&pb.RouteNote{Location: note.Location}); err != nil {
+			log.Fatalf("Failed to join location: %v", err)
 		}
-	}()
+	}
+	listenerDone := printNotes(listener, "Listener")
+
+	stream, err := client.RouteChat(ctx)
+	if err != nil {
+		log.Fatalf("client.RouteChat failed: %v", err)
+	}
+	waitc := printNotes(stream, "Sender")
 
 	for _, note := range notes {
 		log.Printf("Sending message: %v at location: (%d, %d)",
@@ -124,12 +358,84 @@ func routeChat(client pb.RouteGuideClient, ctx context.Context) {
 	}
 	stream.CloseSend()
 	<-waitc
+	listener.CloseSend()
+	<-listenerDone
+}
+
+// transportCredentials picks plaintext or TLS credentials from the flags
+func transportCredentials() (credentials.TransportCredentials, error) {
+	if !*useTLS && *caFile == "" && *certFile == "" && *serverName == "" {
+		return insecure.NewCredentials(), nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: *serverName}
+	if *caFile != "" {
+		data, err := os.ReadFile(*caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no PEM certificates found", *caFile)
+		}
+	}
+	if *certFile != "" || *keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// callCredentials attaches an API key or bearer token to every RPC
+type callCredentials struct {
+	md     map[string]string
+	secure bool
+}
+
+func (c callCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return c.md, nil
+}
+
+// RequireTransportSecurity is true whenever TLS is configured, so the
+// credentials are only sent in plaintext to a server run without TLS
+func (c callCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
+
+// perRPCCredentials returns the -api-key or -token credentials, or nil
+func perRPCCredentials(secure bool) credentials.PerRPCCredentials {
+	md := map[string]string{}
+	if *apiKey != "" {
+		md["x-api-key"] = *apiKey
+	}
+	if *token != "" {
+		md["authorization"] = "Bearer " + *token
+	}
+	if len(md) == 0 {
+		return nil
+	}
+	return callCredentials{md: md, secure: secure}
 }
 
 func main() {
+	flag.Parse()
+	creds, err := transportCredentials()
+	if err != nil {
+		log.Fatalf("TLS setup failed: %v", err)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if callCreds := perRPCCredentials(creds.Info().SecurityProtocol == "tls"); callCreds != nil {
+		if creds.Info().SecurityProtocol != "tls" {
+			log.Printf("warning: sending credentials without TLS")
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(callCreds))
+	}
+
 	// Establish connection to gRPC server
-	conn, err := grpc.NewClient("localhost:50051",
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(*serverAddr, opts...)
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
@@ -145,6 +451,11 @@ func main() {
 	// Call each RPC method
 	getFeature(client, ctx)
 	listFeatures(client, ctx)
+	listFeaturesPaged(client, ctx)
+	listFeaturesInRadius(client, ctx)
+	listFeaturesInPolygon(client, ctx)
+	nearestFeatures(client, ctx)
+	searchFeatures(client, ctx)
 	recordRoute(client, ctx)
 	routeChat(client, ctx)
 }
\ No newline at end of file
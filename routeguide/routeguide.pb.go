@@ -9,6 +9,9 @@ package routeguide
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,30 +24,838 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Category classifies what kind of place a feature is
+type Category int32
+
+const (
+	Category_CATEGORY_UNSPECIFIED Category = 0
+	Category_CATEGORY_LANDMARK    Category = 1
+	Category_CATEGORY_MONUMENT    Category = 2
+	Category_CATEGORY_BUILDING    Category = 3
+	Category_CATEGORY_BRIDGE      Category = 4
+	Category_CATEGORY_PARK        Category = 5
+	Category_CATEGORY_MUSEUM      Category = 6
+	Category_CATEGORY_TRAIL       Category = 7
+	Category_CATEGORY_VIEWPOINT   Category = 8
+	Category_CATEGORY_OTHER       Category = 9
+)
+
+// Enum value maps for Category.
+var (
+	Category_name = map[int32]string{
+		0: "CATEGORY_UNSPECIFIED",
+		1: "CATEGORY_LANDMARK",
+		2: "CATEGORY_MONUMENT",
+		3: "CATEGORY_BUILDING",
+		4: "CATEGORY_BRIDGE",
+		5: "CATEGORY_PARK",
+		6: "CATEGORY_MUSEUM",
+		7: "CATEGORY_TRAIL",
+		8: "CATEGORY_VIEWPOINT",
+		9: "CATEGORY_OTHER",
+	}
+	Category_value = map[string]int32{
+		"CATEGORY_UNSPECIFIED": 0,
+		"CATEGORY_LANDMARK":    1,
+		"CATEGORY_MONUMENT":    2,
+		"CATEGORY_BUILDING":    3,
+		"CATEGORY_BRIDGE":      4,
+		"CATEGORY_PARK":        5,
+		"CATEGORY_MUSEUM":      6,
+		"CATEGORY_TRAIL":       7,
+		"CATEGORY_VIEWPOINT":   8,
+		"CATEGORY_OTHER":       9,
+	}
+)
+
+func (x Category) Enum() *Category {
+	p := new(Category)
+	*p = x
+	return p
+}
+
+func (x Category) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Category) Descriptor() protoreflect.EnumDescriptor {
+	return file_routeguide_routeguide_proto_enumTypes[0].Descriptor()
+}
+
+func (Category) Type() protoreflect.EnumType {
+	return &file_routeguide_routeguide_proto_enumTypes[0]
+}
+
+func (x Category) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Category.Descriptor instead.
+func (Category) EnumDescriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{0}
+}
+
+type SortOrder int32
+
+const (
+	SortOrder_SORT_ORDER_ID SortOrder = 0
+	// Case-sensitive byte order of the name
+	SortOrder_SORT_ORDER_NAME SortOrder = 1
+	// Nearest to reference_point first, by great-circle distance
+	SortOrder_SORT_ORDER_DISTANCE SortOrder = 2
+	// Along a Hilbert curve, so consecutive results are usually close together
+	SortOrder_SORT_ORDER_HILBERT SortOrder = 3
+)
+
+// Enum value maps for SortOrder.
+var (
+	SortOrder_name = map[int32]string{
+		0: "SORT_ORDER_ID",
+		1: "SORT_ORDER_NAME",
+		2: "SORT_ORDER_DISTANCE",
+		3: "SORT_ORDER_HILBERT",
+	}
+	SortOrder_value = map[string]int32{
+		"SORT_ORDER_ID":       0,
+		"SORT_ORDER_NAME":     1,
+		"SORT_ORDER_DISTANCE": 2,
+		"SORT_ORDER_HILBERT":  3,
+	}
+)
+
+func (x SortOrder) Enum() *SortOrder {
+	p := new(SortOrder)
+	*p = x
+	return p
+}
+
+func (x SortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_routeguide_routeguide_proto_enumTypes[1].Descriptor()
+}
+
+func (SortOrder) Type() protoreflect.EnumType {
+	return &file_routeguide_routeguide_proto_enumTypes[1]
+}
+
+func (x SortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortOrder.Descriptor instead.
+func (SortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{1}
+}
+
+type TagPredicate_Op int32
+
+const (
+	TagPredicate_OP_EXISTS     TagPredicate_Op = 0 // the tag is set, to any value
+	TagPredicate_OP_EQUALS     TagPredicate_Op = 1 // the tag is set to value
+	TagPredicate_OP_NOT_EQUALS TagPredicate_Op = 2 // the tag is unset or set to something other than value
+	TagPredicate_OP_ABSENT     TagPredicate_Op = 3 // the tag is unset
+)
+
+// Enum value maps for TagPredicate_Op.
+var (
+	TagPredicate_Op_name = map[int32]string{
+		0: "OP_EXISTS",
+		1: "OP_EQUALS",
+		2: "OP_NOT_EQUALS",
+		3: "OP_ABSENT",
+	}
+	TagPredicate_Op_value = map[string]int32{
+		"OP_EXISTS":     0,
+		"OP_EQUALS":     1,
+		"OP_NOT_EQUALS": 2,
+		"OP_ABSENT":     3,
+	}
+)
+
+func (x TagPredicate_Op) Enum() *TagPredicate_Op {
+	p := new(TagPredicate_Op)
+	*p = x
+	return p
+}
+
+func (x TagPredicate_Op) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TagPredicate_Op) Descriptor() protoreflect.EnumDescriptor {
+	return file_routeguide_routeguide_proto_enumTypes[2].Descriptor()
+}
+
+func (TagPredicate_Op) Type() protoreflect.EnumType {
+	return &file_routeguide_routeguide_proto_enumTypes[2]
+}
+
+func (x TagPredicate_Op) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TagPredicate_Op.Descriptor instead.
+func (TagPredicate_Op) EnumDescriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{8, 0}
+}
+
 // Point represents a geographical coordinate pair
 type Point struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Latitude      int32                  `protobuf:"varint,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
-	Longitude     int32                  `protobuf:"varint,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Latitude      int32                  `protobuf:"varint,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     int32                  `protobuf:"varint,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Point) Reset() {
+	*x = Point{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Point) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Point) ProtoMessage() {}
+
+func (x *Point) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Point.ProtoReflect.Descriptor instead.
+func (*Point) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Point) GetLatitude() int32 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *Point) GetLongitude() int32 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+// Feature represents a named geographical location
+type Feature struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Name     string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Location *Point                 `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	// Stable identifier; kept when the feature is renamed or moved
+	Id       string   `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	Category Category `protobuf:"varint,4,opt,name=category,proto3,enum=routeguide.Category" json:"category,omitempty"`
+	// Free-form labels, e.g. {"access": "free", "wheelchair": "yes"}
+	Tags        map[string]string `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Description string            `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	// Height above sea level in metres
+	ElevationM float64 `protobuf:"fixed64,7,opt,name=elevation_m,json=elevationM,proto3" json:"elevation_m,omitempty"`
+	// Set by the server when the feature is created over the API and
+	// whenever it is updated; features loaded from a file carry the file's values
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Feature) Reset() {
+	*x = Feature{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Feature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Feature) ProtoMessage() {}
+
+func (x *Feature) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Feature.ProtoReflect.Descriptor instead.
+func (*Feature) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Feature) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Feature) GetLocation() *Point {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *Feature) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Feature) GetCategory() Category {
+	if x != nil {
+		return x.Category
+	}
+	return Category_CATEGORY_UNSPECIFIED
+}
+
+func (x *Feature) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Feature) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Feature) GetElevationM() float64 {
+	if x != nil {
+		return x.ElevationM
+	}
+	return 0
+}
+
+func (x *Feature) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Feature) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreateFeatureRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The feature to add. If id is empty the server generates one.
+	Feature       *Feature `protobuf:"bytes,1,opt,name=feature,proto3" json:"feature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateFeatureRequest) Reset() {
+	*x = CreateFeatureRequest{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFeatureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFeatureRequest) ProtoMessage() {}
+
+func (x *CreateFeatureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFeatureRequest.ProtoReflect.Descriptor instead.
+func (*CreateFeatureRequest) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateFeatureRequest) GetFeature() *Feature {
+	if x != nil {
+		return x.Feature
+	}
+	return nil
+}
+
+type UpdateFeatureRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The new values; feature.id selects the feature to change
+	Feature *Feature `protobuf:"bytes,1,opt,name=feature,proto3" json:"feature,omitempty"`
+	// Fields to change: any of "name", "location", "category", "tags",
+	// "description" and "elevation_m". Empty means all of them.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateFeatureRequest) Reset() {
+	*x = UpdateFeatureRequest{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateFeatureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateFeatureRequest) ProtoMessage() {}
+
+func (x *UpdateFeatureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateFeatureRequest.ProtoReflect.Descriptor instead.
+func (*UpdateFeatureRequest) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateFeatureRequest) GetFeature() *Feature {
+	if x != nil {
+		return x.Feature
+	}
+	return nil
+}
+
+func (x *UpdateFeatureRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type DeleteFeatureRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFeatureRequest) Reset() {
+	*x = DeleteFeatureRequest{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFeatureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFeatureRequest) ProtoMessage() {}
+
+func (x *DeleteFeatureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFeatureRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFeatureRequest) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteFeatureRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Rectangle is the latitude/longitude box spanned by two opposite corners.
+// The corners may be given in any order and the bounds are inclusive.
+type Rectangle struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	BottomLeftCorner *Point                 `protobuf:"bytes,1,opt,name=bottomLeftCorner,proto3" json:"bottomLeftCorner,omitempty"`
+	TopRightCorner   *Point                 `protobuf:"bytes,2,opt,name=topRightCorner,proto3" json:"topRightCorner,omitempty"`
+	// When set, the box spans the longitudes outside the corners' range,
+	// i.e. from the eastern corner across the 180° meridian to the western one
+	CrossesAntimeridian bool `protobuf:"varint,3,opt,name=crosses_antimeridian,json=crossesAntimeridian,proto3" json:"crosses_antimeridian,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Rectangle) Reset() {
+	*x = Rectangle{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rectangle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rectangle) ProtoMessage() {}
+
+func (x *Rectangle) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rectangle.ProtoReflect.Descriptor instead.
+func (*Rectangle) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Rectangle) GetBottomLeftCorner() *Point {
+	if x != nil {
+		return x.BottomLeftCorner
+	}
+	return nil
+}
+
+func (x *Rectangle) GetTopRightCorner() *Point {
+	if x != nil {
+		return x.TopRightCorner
+	}
+	return nil
+}
+
+func (x *Rectangle) GetCrossesAntimeridian() bool {
+	if x != nil {
+		return x.CrossesAntimeridian
+	}
+	return false
+}
+
+// ListFeaturesRequest is a Rectangle plus an optional filter. Fields 1-3
+// match Rectangle's, so a serialized Rectangle is a valid request.
+type ListFeaturesRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	BottomLeftCorner    *Point                 `protobuf:"bytes,1,opt,name=bottomLeftCorner,proto3" json:"bottomLeftCorner,omitempty"`
+	TopRightCorner      *Point                 `protobuf:"bytes,2,opt,name=topRightCorner,proto3" json:"topRightCorner,omitempty"`
+	CrossesAntimeridian bool                   `protobuf:"varint,3,opt,name=crosses_antimeridian,json=crossesAntimeridian,proto3" json:"crosses_antimeridian,omitempty"`
+	// Unset returns every feature in the rectangle
+	Filter *FeatureFilter `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`
+	// When page_size is set the server
+	// sends at most that many features (capped at 1000) and, if more remain,
+	// returns a token for the next page in the "next-page-token" trailer.
+	PageSize int32 `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Token from the previous page; the other fields must not change between
+	// pages except page_size. Tokens stay valid across catalogue changes:
+	// the next page resumes after the sort position (name, distance or
+	// Hilbert value, then id) of the last feature sent.
+	PageToken string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Total number of features to return across all pages; 0 means no limit
+	MaxResults int32 `protobuf:"varint,7,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	// Order of the results; ties are broken by feature id
+	OrderBy SortOrder `protobuf:"varint,8,opt,name=order_by,json=orderBy,proto3,enum=routeguide.SortOrder" json:"order_by,omitempty"`
+	// Required with SORT_ORDER_DISTANCE
+	ReferencePoint *Point `protobuf:"bytes,9,opt,name=reference_point,json=referencePoint,proto3" json:"reference_point,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListFeaturesRequest) Reset() {
+	*x = ListFeaturesRequest{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFeaturesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFeaturesRequest) ProtoMessage() {}
+
+func (x *ListFeaturesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFeaturesRequest.ProtoReflect.Descriptor instead.
+func (*ListFeaturesRequest) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListFeaturesRequest) GetBottomLeftCorner() *Point {
+	if x != nil {
+		return x.BottomLeftCorner
+	}
+	return nil
+}
+
+func (x *ListFeaturesRequest) GetTopRightCorner() *Point {
+	if x != nil {
+		return x.TopRightCorner
+	}
+	return nil
+}
+
+func (x *ListFeaturesRequest) GetCrossesAntimeridian() bool {
+	if x != nil {
+		return x.CrossesAntimeridian
+	}
+	return false
+}
+
+func (x *ListFeaturesRequest) GetFilter() *FeatureFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListFeaturesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListFeaturesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListFeaturesRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *ListFeaturesRequest) GetOrderBy() SortOrder {
+	if x != nil {
+		return x.OrderBy
+	}
+	return SortOrder_SORT_ORDER_ID
+}
+
+func (x *ListFeaturesRequest) GetReferencePoint() *Point {
+	if x != nil {
+		return x.ReferencePoint
+	}
+	return nil
+}
+
+// FeatureFilter selects features by name, category and tags. Every condition
+// that is set must hold.
+type FeatureFilter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Case-insensitive match against the start of the name
+	NamePrefix string `protobuf:"bytes,1,opt,name=name_prefix,json=namePrefix,proto3" json:"name_prefix,omitempty"`
+	// Case-insensitive match anywhere in the name
+	NameContains string `protobuf:"bytes,2,opt,name=name_contains,json=nameContains,proto3" json:"name_contains,omitempty"`
+	// The feature's category must be one of these; empty allows any
+	Categories    []Category      `protobuf:"varint,3,rep,packed,name=categories,proto3,enum=routeguide.Category" json:"categories,omitempty"`
+	Tags          []*TagPredicate `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeatureFilter) Reset() {
+	*x = FeatureFilter{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeatureFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeatureFilter) ProtoMessage() {}
+
+func (x *FeatureFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeatureFilter.ProtoReflect.Descriptor instead.
+func (*FeatureFilter) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FeatureFilter) GetNamePrefix() string {
+	if x != nil {
+		return x.NamePrefix
+	}
+	return ""
+}
+
+func (x *FeatureFilter) GetNameContains() string {
+	if x != nil {
+		return x.NameContains
+	}
+	return ""
+}
+
+func (x *FeatureFilter) GetCategories() []Category {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *FeatureFilter) GetTags() []*TagPredicate {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+// TagPredicate tests one tag of a feature
+type TagPredicate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Op            TagPredicate_Op        `protobuf:"varint,2,opt,name=op,proto3,enum=routeguide.TagPredicate_Op" json:"op,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TagPredicate) Reset() {
+	*x = TagPredicate{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagPredicate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagPredicate) ProtoMessage() {}
+
+func (x *TagPredicate) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagPredicate.ProtoReflect.Descriptor instead.
+func (*TagPredicate) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TagPredicate) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TagPredicate) GetOp() TagPredicate_Op {
+	if x != nil {
+		return x.Op
+	}
+	return TagPredicate_OP_EXISTS
+}
+
+func (x *TagPredicate) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// Circle is the area within radius_m metres (great-circle distance) of center
+type Circle struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Center        *Point                 `protobuf:"bytes,1,opt,name=center,proto3" json:"center,omitempty"`
+	RadiusM       float64                `protobuf:"fixed64,2,opt,name=radius_m,json=radiusM,proto3" json:"radius_m,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Point) Reset() {
-	*x = Point{}
-	mi := &file_routeguide_routeguide_proto_msgTypes[0]
+func (x *Circle) Reset() {
+	*x = Circle{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Point) String() string {
+func (x *Circle) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Point) ProtoMessage() {}
+func (*Circle) ProtoMessage() {}
 
-func (x *Point) ProtoReflect() protoreflect.Message {
-	mi := &file_routeguide_routeguide_proto_msgTypes[0]
+func (x *Circle) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -55,49 +866,49 @@ func (x *Point) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Point.ProtoReflect.Descriptor instead.
-func (*Point) Descriptor() ([]byte, []int) {
-	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use Circle.ProtoReflect.Descriptor instead.
+func (*Circle) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *Point) GetLatitude() int32 {
+func (x *Circle) GetCenter() *Point {
 	if x != nil {
-		return x.Latitude
+		return x.Center
 	}
-	return 0
+	return nil
 }
 
-func (x *Point) GetLongitude() int32 {
+func (x *Circle) GetRadiusM() float64 {
 	if x != nil {
-		return x.Longitude
+		return x.RadiusM
 	}
 	return 0
 }
 
-// Feature represents a named geographical location
-type Feature struct {
+// LinearRing is an ordered ring of at least three distinct points.
+// The ring is closed implicitly; repeating the first point at the end is allowed.
+type LinearRing struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Location      *Point                 `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Points        []*Point               `protobuf:"bytes,1,rep,name=points,proto3" json:"points,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Feature) Reset() {
-	*x = Feature{}
-	mi := &file_routeguide_routeguide_proto_msgTypes[1]
+func (x *LinearRing) Reset() {
+	*x = LinearRing{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Feature) String() string {
+func (x *LinearRing) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Feature) ProtoMessage() {}
+func (*LinearRing) ProtoMessage() {}
 
-func (x *Feature) ProtoReflect() protoreflect.Message {
-	mi := &file_routeguide_routeguide_proto_msgTypes[1]
+func (x *LinearRing) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -108,48 +919,100 @@ func (x *Feature) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Feature.ProtoReflect.Descriptor instead.
-func (*Feature) Descriptor() ([]byte, []int) {
-	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use LinearRing.ProtoReflect.Descriptor instead.
+func (*LinearRing) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *Feature) GetName() string {
+func (x *LinearRing) GetPoints() []*Point {
 	if x != nil {
-		return x.Name
+		return x.Points
 	}
-	return ""
+	return nil
 }
 
-func (x *Feature) GetLocation() *Point {
+// Polygon is an area bounded by an outer ring, minus any holes. Edges are
+// straight lines in latitude/longitude space and must not cross each other.
+// Holes lie inside the outer ring without touching it or one another.
+// A polygon has at most 100 holes and 10000 points across all its rings.
+type Polygon struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Points        []*Point               `protobuf:"bytes,1,rep,name=points,proto3" json:"points,omitempty"`
+	Holes         []*LinearRing          `protobuf:"bytes,2,rep,name=holes,proto3" json:"holes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Polygon) Reset() {
+	*x = Polygon{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Polygon) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Polygon) ProtoMessage() {}
+
+func (x *Polygon) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[11]
 	if x != nil {
-		return x.Location
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Polygon.ProtoReflect.Descriptor instead.
+func (*Polygon) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Polygon) GetPoints() []*Point {
+	if x != nil {
+		return x.Points
 	}
 	return nil
 }
 
-type Rectangle struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	BottomLeftCorner *Point                 `protobuf:"bytes,1,opt,name=bottomLeftCorner,proto3" json:"bottomLeftCorner,omitempty"`
-	TopRightCorner   *Point                 `protobuf:"bytes,2,opt,name=topRightCorner,proto3" json:"topRightCorner,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *Polygon) GetHoles() []*LinearRing {
+	if x != nil {
+		return x.Holes
+	}
+	return nil
 }
 
-func (x *Rectangle) Reset() {
-	*x = Rectangle{}
-	mi := &file_routeguide_routeguide_proto_msgTypes[2]
+// NearestRequest asks for the k features closest to a point
+type NearestRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Point *Point                 `protobuf:"bytes,1,opt,name=point,proto3" json:"point,omitempty"`
+	K     int32                  `protobuf:"varint,2,opt,name=k,proto3" json:"k,omitempty"`
+	// Only features within this many metres are returned; 0 means no limit
+	MaxDistanceM  float64 `protobuf:"fixed64,3,opt,name=max_distance_m,json=maxDistanceM,proto3" json:"max_distance_m,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NearestRequest) Reset() {
+	*x = NearestRequest{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Rectangle) String() string {
+func (x *NearestRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Rectangle) ProtoMessage() {}
+func (*NearestRequest) ProtoMessage() {}
 
-func (x *Rectangle) ProtoReflect() protoreflect.Message {
-	mi := &file_routeguide_routeguide_proto_msgTypes[2]
+func (x *NearestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -160,36 +1023,209 @@ func (x *Rectangle) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Rectangle.ProtoReflect.Descriptor instead.
-func (*Rectangle) Descriptor() ([]byte, []int) {
-	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use NearestRequest.ProtoReflect.Descriptor instead.
+func (*NearestRequest) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *Rectangle) GetBottomLeftCorner() *Point {
+func (x *NearestRequest) GetPoint() *Point {
 	if x != nil {
-		return x.BottomLeftCorner
+		return x.Point
 	}
 	return nil
 }
 
-func (x *Rectangle) GetTopRightCorner() *Point {
+func (x *NearestRequest) GetK() int32 {
 	if x != nil {
-		return x.TopRightCorner
+		return x.K
+	}
+	return 0
+}
+
+func (x *NearestRequest) GetMaxDistanceM() float64 {
+	if x != nil {
+		return x.MaxDistanceM
+	}
+	return 0
+}
+
+// FeatureDistance is a feature together with its distance from the query point
+type FeatureDistance struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Feature       *Feature               `protobuf:"bytes,1,opt,name=feature,proto3" json:"feature,omitempty"`
+	DistanceM     float64                `protobuf:"fixed64,2,opt,name=distance_m,json=distanceM,proto3" json:"distance_m,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeatureDistance) Reset() {
+	*x = FeatureDistance{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeatureDistance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeatureDistance) ProtoMessage() {}
+
+func (x *FeatureDistance) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeatureDistance.ProtoReflect.Descriptor instead.
+func (*FeatureDistance) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *FeatureDistance) GetFeature() *Feature {
+	if x != nil {
+		return x.Feature
 	}
 	return nil
 }
 
-type RouteSummary struct {
+func (x *FeatureDistance) GetDistanceM() float64 {
+	if x != nil {
+		return x.DistanceM
+	}
+	return 0
+}
+
+// SearchRequest is a free-text query over feature names and descriptions.
+// Matching ignores case and punctuation, and each query word also matches
+// longer words it is a prefix of, so partial input works for autocomplete.
+type SearchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Maximum number of results; 0 means 10
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// SearchResult is a matching feature and its relevance; higher scores are better
+type SearchResult struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	PointCount    int32                  `protobuf:"varint,1,opt,name=point_count,json=pointCount,proto3" json:"point_count,omitempty"`
-	FeatureCount  int32                  `protobuf:"varint,2,opt,name=feature_count,json=featureCount,proto3" json:"feature_count,omitempty"`
+	Feature       *Feature               `protobuf:"bytes,1,opt,name=feature,proto3" json:"feature,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResult) Reset() {
+	*x = SearchResult{}
+	mi := &file_routeguide_routeguide_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResult) ProtoMessage() {}
+
+func (x *SearchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_routeguide_routeguide_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResult.ProtoReflect.Descriptor instead.
+func (*SearchResult) Descriptor() ([]byte, []int) {
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SearchResult) GetFeature() *Feature {
+	if x != nil {
+		return x.Feature
+	}
+	return nil
+}
+
+func (x *SearchResult) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type RouteSummary struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	PointCount   int32                  `protobuf:"varint,1,opt,name=point_count,json=pointCount,proto3" json:"point_count,omitempty"`
+	FeatureCount int32                  `protobuf:"varint,2,opt,name=feature_count,json=featureCount,proto3" json:"feature_count,omitempty"`
+	// Distance covered between consecutive points, in metres
+	Distance int32 `protobuf:"varint,3,opt,name=distance,proto3" json:"distance,omitempty"`
+	// Seconds from the first to the last received point
+	ElapsedTime   int32 `protobuf:"varint,4,opt,name=elapsed_time,json=elapsedTime,proto3" json:"elapsed_time,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RouteSummary) Reset() {
 	*x = RouteSummary{}
-	mi := &file_routeguide_routeguide_proto_msgTypes[3]
+	mi := &file_routeguide_routeguide_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -201,7 +1237,7 @@ func (x *RouteSummary) String() string {
 func (*RouteSummary) ProtoMessage() {}
 
 func (x *RouteSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_routeguide_routeguide_proto_msgTypes[3]
+	mi := &file_routeguide_routeguide_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -214,7 +1250,7 @@ func (x *RouteSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RouteSummary.ProtoReflect.Descriptor instead.
 func (*RouteSummary) Descriptor() ([]byte, []int) {
-	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{3}
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *RouteSummary) GetPointCount() int32 {
@@ -231,17 +1267,41 @@ func (x *RouteSummary) GetFeatureCount() int32 {
 	return 0
 }
 
+func (x *RouteSummary) GetDistance() int32 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *RouteSummary) GetElapsedTime() int32 {
+	if x != nil {
+		return x.ElapsedTime
+	}
+	return 0
+}
+
 type RouteNote struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Location      *Point                 `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Location *Point                 `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	Message  string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Server-assigned, increasing across all locations; set on notes sent by the server
+	Sequence uint64 `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Set by the client on the note that joins a location to resume after a
+	// reconnect: only history with a greater sequence is replayed. 0 replays everything.
+	ResumeAfter uint64 `protobuf:"varint,4,opt,name=resume_after,json=resumeAfter,proto3" json:"resume_after,omitempty"`
+	// Server-assigned time the note was stored; notes expire relative to it
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Server-assigned subject of the authenticated caller that posted the
+	// note; empty when the server does not require authentication
+	Author        string `protobuf:"bytes,6,opt,name=author,proto3" json:"author,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RouteNote) Reset() {
 	*x = RouteNote{}
-	mi := &file_routeguide_routeguide_proto_msgTypes[4]
+	mi := &file_routeguide_routeguide_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -253,7 +1313,7 @@ func (x *RouteNote) String() string {
 func (*RouteNote) ProtoMessage() {}
 
 func (x *RouteNote) ProtoReflect() protoreflect.Message {
-	mi := &file_routeguide_routeguide_proto_msgTypes[4]
+	mi := &file_routeguide_routeguide_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -266,7 +1326,7 @@ func (x *RouteNote) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RouteNote.ProtoReflect.Descriptor instead.
 func (*RouteNote) Descriptor() ([]byte, []int) {
-	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{4}
+	return file_routeguide_routeguide_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *RouteNote) GetLocation() *Point {
@@ -283,35 +1343,167 @@ func (x *RouteNote) GetMessage() string {
 	return ""
 }
 
+func (x *RouteNote) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *RouteNote) GetResumeAfter() uint64 {
+	if x != nil {
+		return x.ResumeAfter
+	}
+	return 0
+}
+
+func (x *RouteNote) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *RouteNote) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
 var File_routeguide_routeguide_proto protoreflect.FileDescriptor
 
 const file_routeguide_routeguide_proto_rawDesc = "" +
 	"\n" +
 	"\x1brouteguide/routeguide.proto\x12\n" +
-	"routeguide\"A\n" +
+	"routeguide\x1a\x1bgoogle/protobuf/empty.proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"A\n" +
 	"\x05Point\x12\x1a\n" +
 	"\blatitude\x18\x01 \x01(\x05R\blatitude\x12\x1c\n" +
-	"\tlongitude\x18\x02 \x01(\x05R\tlongitude\"L\n" +
+	"\tlongitude\x18\x02 \x01(\x05R\tlongitude\"\xb3\x03\n" +
 	"\aFeature\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12-\n" +
-	"\blocation\x18\x02 \x01(\v2\x11.routeguide.PointR\blocation\"\x85\x01\n" +
+	"\blocation\x18\x02 \x01(\v2\x11.routeguide.PointR\blocation\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x120\n" +
+	"\bcategory\x18\x04 \x01(\x0e2\x14.routeguide.CategoryR\bcategory\x121\n" +
+	"\x04tags\x18\x05 \x03(\v2\x1d.routeguide.Feature.TagsEntryR\x04tags\x12 \n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\x12\x1f\n" +
+	"\velevation_m\x18\a \x01(\x01R\n" +
+	"elevationM\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"E\n" +
+	"\x14CreateFeatureRequest\x12-\n" +
+	"\afeature\x18\x01 \x01(\v2\x13.routeguide.FeatureR\afeature\"\x82\x01\n" +
+	"\x14UpdateFeatureRequest\x12-\n" +
+	"\afeature\x18\x01 \x01(\v2\x13.routeguide.FeatureR\afeature\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"&\n" +
+	"\x14DeleteFeatureRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xb8\x01\n" +
 	"\tRectangle\x12=\n" +
 	"\x10bottomLeftCorner\x18\x01 \x01(\v2\x11.routeguide.PointR\x10bottomLeftCorner\x129\n" +
-	"\x0etopRightCorner\x18\x02 \x01(\v2\x11.routeguide.PointR\x0etopRightCorner\"T\n" +
+	"\x0etopRightCorner\x18\x02 \x01(\v2\x11.routeguide.PointR\x0etopRightCorner\x121\n" +
+	"\x14crosses_antimeridian\x18\x03 \x01(\bR\x13crossesAntimeridian\"\xc0\x03\n" +
+	"\x13ListFeaturesRequest\x12=\n" +
+	"\x10bottomLeftCorner\x18\x01 \x01(\v2\x11.routeguide.PointR\x10bottomLeftCorner\x129\n" +
+	"\x0etopRightCorner\x18\x02 \x01(\v2\x11.routeguide.PointR\x0etopRightCorner\x121\n" +
+	"\x14crosses_antimeridian\x18\x03 \x01(\bR\x13crossesAntimeridian\x121\n" +
+	"\x06filter\x18\x04 \x01(\v2\x19.routeguide.FeatureFilterR\x06filter\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\x12\x1f\n" +
+	"\vmax_results\x18\a \x01(\x05R\n" +
+	"maxResults\x120\n" +
+	"\border_by\x18\b \x01(\x0e2\x15.routeguide.SortOrderR\aorderBy\x12:\n" +
+	"\x0freference_point\x18\t \x01(\v2\x11.routeguide.PointR\x0ereferencePoint\"\xb9\x01\n" +
+	"\rFeatureFilter\x12\x1f\n" +
+	"\vname_prefix\x18\x01 \x01(\tR\n" +
+	"namePrefix\x12#\n" +
+	"\rname_contains\x18\x02 \x01(\tR\fnameContains\x124\n" +
+	"\n" +
+	"categories\x18\x03 \x03(\x0e2\x14.routeguide.CategoryR\n" +
+	"categories\x12,\n" +
+	"\x04tags\x18\x04 \x03(\v2\x18.routeguide.TagPredicateR\x04tags\"\xa9\x01\n" +
+	"\fTagPredicate\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
+	"\x02op\x18\x02 \x01(\x0e2\x1b.routeguide.TagPredicate.OpR\x02op\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"D\n" +
+	"\x02Op\x12\r\n" +
+	"\tOP_EXISTS\x10\x00\x12\r\n" +
+	"\tOP_EQUALS\x10\x01\x12\x11\n" +
+	"\rOP_NOT_EQUALS\x10\x02\x12\r\n" +
+	"\tOP_ABSENT\x10\x03\"N\n" +
+	"\x06Circle\x12)\n" +
+	"\x06center\x18\x01 \x01(\v2\x11.routeguide.PointR\x06center\x12\x19\n" +
+	"\bradius_m\x18\x02 \x01(\x01R\aradiusM\"7\n" +
+	"\n" +
+	"LinearRing\x12)\n" +
+	"\x06points\x18\x01 \x03(\v2\x11.routeguide.PointR\x06points\"b\n" +
+	"\aPolygon\x12)\n" +
+	"\x06points\x18\x01 \x03(\v2\x11.routeguide.PointR\x06points\x12,\n" +
+	"\x05holes\x18\x02 \x03(\v2\x16.routeguide.LinearRingR\x05holes\"m\n" +
+	"\x0eNearestRequest\x12'\n" +
+	"\x05point\x18\x01 \x01(\v2\x11.routeguide.PointR\x05point\x12\f\n" +
+	"\x01k\x18\x02 \x01(\x05R\x01k\x12$\n" +
+	"\x0emax_distance_m\x18\x03 \x01(\x01R\fmaxDistanceM\"_\n" +
+	"\x0fFeatureDistance\x12-\n" +
+	"\afeature\x18\x01 \x01(\v2\x13.routeguide.FeatureR\afeature\x12\x1d\n" +
+	"\n" +
+	"distance_m\x18\x02 \x01(\x01R\tdistanceM\";\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"S\n" +
+	"\fSearchResult\x12-\n" +
+	"\afeature\x18\x01 \x01(\v2\x13.routeguide.FeatureR\afeature\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\"\x93\x01\n" +
 	"\fRouteSummary\x12\x1f\n" +
 	"\vpoint_count\x18\x01 \x01(\x05R\n" +
 	"pointCount\x12#\n" +
-	"\rfeature_count\x18\x02 \x01(\x05R\ffeatureCount\"T\n" +
+	"\rfeature_count\x18\x02 \x01(\x05R\ffeatureCount\x12\x1a\n" +
+	"\bdistance\x18\x03 \x01(\x05R\bdistance\x12!\n" +
+	"\felapsed_time\x18\x04 \x01(\x05R\velapsedTime\"\xe6\x01\n" +
 	"\tRouteNote\x12-\n" +
 	"\blocation\x18\x01 \x01(\v2\x11.routeguide.PointR\blocation\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\x85\x02\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x04R\bsequence\x12!\n" +
+	"\fresume_after\x18\x04 \x01(\x04R\vresumeAfter\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x16\n" +
+	"\x06author\x18\x06 \x01(\tR\x06author*\xe6\x01\n" +
+	"\bCategory\x12\x18\n" +
+	"\x14CATEGORY_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11CATEGORY_LANDMARK\x10\x01\x12\x15\n" +
+	"\x11CATEGORY_MONUMENT\x10\x02\x12\x15\n" +
+	"\x11CATEGORY_BUILDING\x10\x03\x12\x13\n" +
+	"\x0fCATEGORY_BRIDGE\x10\x04\x12\x11\n" +
+	"\rCATEGORY_PARK\x10\x05\x12\x13\n" +
+	"\x0fCATEGORY_MUSEUM\x10\x06\x12\x12\n" +
+	"\x0eCATEGORY_TRAIL\x10\a\x12\x16\n" +
+	"\x12CATEGORY_VIEWPOINT\x10\b\x12\x12\n" +
+	"\x0eCATEGORY_OTHER\x10\t*d\n" +
+	"\tSortOrder\x12\x11\n" +
+	"\rSORT_ORDER_ID\x10\x00\x12\x13\n" +
+	"\x0fSORT_ORDER_NAME\x10\x01\x12\x17\n" +
+	"\x13SORT_ORDER_DISTANCE\x10\x02\x12\x16\n" +
+	"\x12SORT_ORDER_HILBERT\x10\x032\x97\x06\n" +
 	"\n" +
 	"RouteGuide\x126\n" +
 	"\n" +
-	"GetFeature\x12\x11.routeguide.Point\x1a\x13.routeguide.Feature\"\x00\x12>\n" +
-	"\fListFeatures\x12\x15.routeguide.Rectangle\x1a\x13.routeguide.Feature\"\x000\x01\x12>\n" +
-	"\vRecordRoute\x12\x11.routeguide.Point\x1a\x18.routeguide.RouteSummary\"\x00(\x01\x12?\n" +
-	"\tRouteChat\x12\x15.routeguide.RouteNote\x1a\x15.routeguide.RouteNote\"\x00(\x010\x01B\x17Z\x15routeguide/routeguideb\x06proto3"
+	"GetFeature\x12\x11.routeguide.Point\x1a\x13.routeguide.Feature\"\x00\x12H\n" +
+	"\fListFeatures\x12\x1f.routeguide.ListFeaturesRequest\x1a\x13.routeguide.Feature\"\x000\x01\x12C\n" +
+	"\x14ListFeaturesInRadius\x12\x12.routeguide.Circle\x1a\x13.routeguide.Feature\"\x000\x01\x12>\n" +
+	"\vRecordRoute\x12\x11.routeguide.Point\x1a\x18.routeguide.RouteSummary\"\x00(\x01\x12E\n" +
+	"\x15ListFeaturesInPolygon\x12\x13.routeguide.Polygon\x1a\x13.routeguide.Feature\"\x000\x01\x12N\n" +
+	"\x0fNearestFeatures\x12\x1a.routeguide.NearestRequest\x1a\x1b.routeguide.FeatureDistance\"\x000\x01\x12I\n" +
+	"\x0eSearchFeatures\x12\x19.routeguide.SearchRequest\x1a\x18.routeguide.SearchResult\"\x000\x01\x12?\n" +
+	"\tRouteChat\x12\x15.routeguide.RouteNote\x1a\x15.routeguide.RouteNote\"\x00(\x010\x01\x12H\n" +
+	"\rCreateFeature\x12 .routeguide.CreateFeatureRequest\x1a\x13.routeguide.Feature\"\x00\x12H\n" +
+	"\rUpdateFeature\x12 .routeguide.UpdateFeatureRequest\x1a\x13.routeguide.Feature\"\x00\x12K\n" +
+	"\rDeleteFeature\x12 .routeguide.DeleteFeatureRequest\x1a\x16.google.protobuf.Empty\"\x00B\x17Z\x15routeguide/routeguideb\x06proto3"
 
 var (
 	file_routeguide_routeguide_proto_rawDescOnce sync.Once
@@ -325,32 +1517,90 @@ func file_routeguide_routeguide_proto_rawDescGZIP() []byte {
 	return file_routeguide_routeguide_proto_rawDescData
 }
 
-var file_routeguide_routeguide_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_routeguide_routeguide_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_routeguide_routeguide_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_routeguide_routeguide_proto_goTypes = []any{
-	(*Point)(nil),        // 0: routeguide.Point
-	(*Feature)(nil),      // 1: routeguide.Feature
-	(*Rectangle)(nil),    // 2: routeguide.Rectangle
-	(*RouteSummary)(nil), // 3: routeguide.RouteSummary
-	(*RouteNote)(nil),    // 4: routeguide.RouteNote
+	(Category)(0),                 // 0: routeguide.Category
+	(SortOrder)(0),                // 1: routeguide.SortOrder
+	(TagPredicate_Op)(0),          // 2: routeguide.TagPredicate.Op
+	(*Point)(nil),                 // 3: routeguide.Point
+	(*Feature)(nil),               // 4: routeguide.Feature
+	(*CreateFeatureRequest)(nil),  // 5: routeguide.CreateFeatureRequest
+	(*UpdateFeatureRequest)(nil),  // 6: routeguide.UpdateFeatureRequest
+	(*DeleteFeatureRequest)(nil),  // 7: routeguide.DeleteFeatureRequest
+	(*Rectangle)(nil),             // 8: routeguide.Rectangle
+	(*ListFeaturesRequest)(nil),   // 9: routeguide.ListFeaturesRequest
+	(*FeatureFilter)(nil),         // 10: routeguide.FeatureFilter
+	(*TagPredicate)(nil),          // 11: routeguide.TagPredicate
+	(*Circle)(nil),                // 12: routeguide.Circle
+	(*LinearRing)(nil),            // 13: routeguide.LinearRing
+	(*Polygon)(nil),               // 14: routeguide.Polygon
+	(*NearestRequest)(nil),        // 15: routeguide.NearestRequest
+	(*FeatureDistance)(nil),       // 16: routeguide.FeatureDistance
+	(*SearchRequest)(nil),         // 17: routeguide.SearchRequest
+	(*SearchResult)(nil),          // 18: routeguide.SearchResult
+	(*RouteSummary)(nil),          // 19: routeguide.RouteSummary
+	(*RouteNote)(nil),             // 20: routeguide.RouteNote
+	nil,                           // 21: routeguide.Feature.TagsEntry
+	(*timestamppb.Timestamp)(nil), // 22: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil), // 23: google.protobuf.FieldMask
+	(*emptypb.Empty)(nil),         // 24: google.protobuf.Empty
 }
 var file_routeguide_routeguide_proto_depIdxs = []int32{
-	0, // 0: routeguide.Feature.location:type_name -> routeguide.Point
-	0, // 1: routeguide.Rectangle.bottomLeftCorner:type_name -> routeguide.Point
-	0, // 2: routeguide.Rectangle.topRightCorner:type_name -> routeguide.Point
-	0, // 3: routeguide.RouteNote.location:type_name -> routeguide.Point
-	0, // 4: routeguide.RouteGuide.GetFeature:input_type -> routeguide.Point
-	2, // 5: routeguide.RouteGuide.ListFeatures:input_type -> routeguide.Rectangle
-	0, // 6: routeguide.RouteGuide.RecordRoute:input_type -> routeguide.Point
-	4, // 7: routeguide.RouteGuide.RouteChat:input_type -> routeguide.RouteNote
-	1, // 8: routeguide.RouteGuide.GetFeature:output_type -> routeguide.Feature
-	1, // 9: routeguide.RouteGuide.ListFeatures:output_type -> routeguide.Feature
-	3, // 10: routeguide.RouteGuide.RecordRoute:output_type -> routeguide.RouteSummary
-	4, // 11: routeguide.RouteGuide.RouteChat:output_type -> routeguide.RouteNote
-	8, // [8:12] is the sub-list for method output_type
-	4, // [4:8] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	3,  // 0: routeguide.Feature.location:type_name -> routeguide.Point
+	0,  // 1: routeguide.Feature.category:type_name -> routeguide.Category
+	21, // 2: routeguide.Feature.tags:type_name -> routeguide.Feature.TagsEntry
+	22, // 3: routeguide.Feature.created_at:type_name -> google.protobuf.Timestamp
+	22, // 4: routeguide.Feature.updated_at:type_name -> google.protobuf.Timestamp
+	4,  // 5: routeguide.CreateFeatureRequest.feature:type_name -> routeguide.Feature
+	4,  // 6: routeguide.UpdateFeatureRequest.feature:type_name -> routeguide.Feature
+	23, // 7: routeguide.UpdateFeatureRequest.update_mask:type_name -> google.protobuf.FieldMask
+	3,  // 8: routeguide.Rectangle.bottomLeftCorner:type_name -> routeguide.Point
+	3,  // 9: routeguide.Rectangle.topRightCorner:type_name -> routeguide.Point
+	3,  // 10: routeguide.ListFeaturesRequest.bottomLeftCorner:type_name -> routeguide.Point
+	3,  // 11: routeguide.ListFeaturesRequest.topRightCorner:type_name -> routeguide.Point
+	10, // 12: routeguide.ListFeaturesRequest.filter:type_name -> routeguide.FeatureFilter
+	1,  // 13: routeguide.ListFeaturesRequest.order_by:type_name -> routeguide.SortOrder
+	3,  // 14: routeguide.ListFeaturesRequest.reference_point:type_name -> routeguide.Point
+	0,  // 15: routeguide.FeatureFilter.categories:type_name -> routeguide.Category
+	11, // 16: routeguide.FeatureFilter.tags:type_name -> routeguide.TagPredicate
+	2,  // 17: routeguide.TagPredicate.op:type_name -> routeguide.TagPredicate.Op
+	3,  // 18: routeguide.Circle.center:type_name -> routeguide.Point
+	3,  // 19: routeguide.LinearRing.points:type_name -> routeguide.Point
+	3,  // 20: routeguide.Polygon.points:type_name -> routeguide.Point
+	13, // 21: routeguide.Polygon.holes:type_name -> routeguide.LinearRing
+	3,  // 22: routeguide.NearestRequest.point:type_name -> routeguide.Point
+	4,  // 23: routeguide.FeatureDistance.feature:type_name -> routeguide.Feature
+	4,  // 24: routeguide.SearchResult.feature:type_name -> routeguide.Feature
+	3,  // 25: routeguide.RouteNote.location:type_name -> routeguide.Point
+	22, // 26: routeguide.RouteNote.created_at:type_name -> google.protobuf.Timestamp
+	3,  // 27: routeguide.RouteGuide.GetFeature:input_type -> routeguide.Point
+	9,  // 28: routeguide.RouteGuide.ListFeatures:input_type -> routeguide.ListFeaturesRequest
+	12, // 29: routeguide.RouteGuide.ListFeaturesInRadius:input_type -> routeguide.Circle
+	3,  // 30: routeguide.RouteGuide.RecordRoute:input_type -> routeguide.Point
+	14, // 31: routeguide.RouteGuide.ListFeaturesInPolygon:input_type -> routeguide.Polygon
+	15, // 32: routeguide.RouteGuide.NearestFeatures:input_type -> routeguide.NearestRequest
+	17, // 33: routeguide.RouteGuide.SearchFeatures:input_type -> routeguide.SearchRequest
+	20, // 34: routeguide.RouteGuide.RouteChat:input_type -> routeguide.RouteNote
+	5,  // 35: routeguide.RouteGuide.CreateFeature:input_type -> routeguide.CreateFeatureRequest
+	6,  // 36: routeguide.RouteGuide.UpdateFeature:input_type -> routeguide.UpdateFeatureRequest
+	7,  // 37: routeguide.RouteGuide.DeleteFeature:input_type -> routeguide.DeleteFeatureRequest
+	4,  // 38: routeguide.RouteGuide.GetFeature:output_type -> routeguide.Feature
+	4,  // 39: routeguide.RouteGuide.ListFeatures:output_type -> routeguide.Feature
+	4,  // 40: routeguide.RouteGuide.ListFeaturesInRadius:output_type -> routeguide.Feature
+	19, // 41: routeguide.RouteGuide.RecordRoute:output_type -> routeguide.RouteSummary
+	4,  // 42: routeguide.RouteGuide.ListFeaturesInPolygon:output_type -> routeguide.Feature
+	16, // 43: routeguide.RouteGuide.NearestFeatures:output_type -> routeguide.FeatureDistance
+	18, // 44: routeguide.RouteGuide.SearchFeatures:output_type -> routeguide.SearchResult
+	20, // 45: routeguide.RouteGuide.RouteChat:output_type -> routeguide.RouteNote
+	4,  // 46: routeguide.RouteGuide.CreateFeature:output_type -> routeguide.Feature
+	4,  // 47: routeguide.RouteGuide.UpdateFeature:output_type -> routeguide.Feature
+	24, // 48: routeguide.RouteGuide.DeleteFeature:output_type -> google.protobuf.Empty
+	38, // [38:49] is the sub-list for method output_type
+	27, // [27:38] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
 }
 
 func init() { file_routeguide_routeguide_proto_init() }
@@ -363,13 +1613,14 @@ func file_routeguide_routeguide_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_routeguide_routeguide_proto_rawDesc), len(file_routeguide_routeguide_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   5,
+			NumEnums:      3,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_routeguide_routeguide_proto_goTypes,
 		DependencyIndexes: file_routeguide_routeguide_proto_depIdxs,
+		EnumInfos:         file_routeguide_routeguide_proto_enumTypes,
 		MessageInfos:      file_routeguide_routeguide_proto_msgTypes,
 	}.Build()
 	File_routeguide_routeguide_proto = out.File
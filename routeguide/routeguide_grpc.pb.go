@@ -11,6 +11,7 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -19,10 +20,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	RouteGuide_GetFeature_FullMethodName   = "/routeguide.RouteGuide/GetFeature"
-	RouteGuide_ListFeatures_FullMethodName = "/routeguide.RouteGuide/ListFeatures"
-	RouteGuide_RecordRoute_FullMethodName  = "/routeguide.RouteGuide/RecordRoute"
-	RouteGuide_RouteChat_FullMethodName    = "/routeguide.RouteGuide/RouteChat"
+	RouteGuide_GetFeature_FullMethodName            = "/routeguide.RouteGuide/GetFeature"
+	RouteGuide_ListFeatures_FullMethodName          = "/routeguide.RouteGuide/ListFeatures"
+	RouteGuide_ListFeaturesInRadius_FullMethodName  = "/routeguide.RouteGuide/ListFeaturesInRadius"
+	RouteGuide_RecordRoute_FullMethodName           = "/routeguide.RouteGuide/RecordRoute"
+	RouteGuide_ListFeaturesInPolygon_FullMethodName = "/routeguide.RouteGuide/ListFeaturesInPolygon"
+	RouteGuide_NearestFeatures_FullMethodName       = "/routeguide.RouteGuide/NearestFeatures"
+	RouteGuide_SearchFeatures_FullMethodName        = "/routeguide.RouteGuide/SearchFeatures"
+	RouteGuide_RouteChat_FullMethodName             = "/routeguide.RouteGuide/RouteChat"
+	RouteGuide_CreateFeature_FullMethodName         = "/routeguide.RouteGuide/CreateFeature"
+	RouteGuide_UpdateFeature_FullMethodName         = "/routeguide.RouteGuide/UpdateFeature"
+	RouteGuide_DeleteFeature_FullMethodName         = "/routeguide.RouteGuide/DeleteFeature"
 )
 
 // RouteGuideClient is the client API for RouteGuide service.
@@ -34,16 +42,44 @@ type RouteGuideClient interface {
 	// Obtains the feature at a given position.
 	GetFeature(ctx context.Context, in *Point, opts ...grpc.CallOption) (*Feature, error)
 	// A server-to-client streaming RPC
-	// Lists all features within given rectangle. Results are streamed
-	// instead of returned all at once
-	ListFeatures(ctx context.Context, in *Rectangle, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error)
+	// Client sends a rectangel and the Server returns all features within given rectangle.
+	// Results are returned as a instead of returned all at once
+	// An optional filter narrows the results on the server.
+	ListFeatures(ctx context.Context, in *ListFeaturesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error)
+	// A server-to-client streaming RPC
+	// Client sends a circle and the Server returns all features within radius_m
+	// metres of its center, boundary included
+	ListFeaturesInRadius(ctx context.Context, in *Circle, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error)
 	// A client-to-server streaming RPC
 	// Client sends a stream of points of its route
 	// and server returns a route summary
 	RecordRoute(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Point, RouteSummary], error)
+	// A server-to-client streaming RPC
+	// Client sends a polygon and the Server returns all features inside it,
+	// boundary included and holes excluded
+	ListFeaturesInPolygon(ctx context.Context, in *Polygon, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error)
+	// A server-to-client streaming RPC
+	// Client sends a point and the Server returns up to k features closest to it,
+	// nearest first, each with its great-circle distance
+	NearestFeatures(ctx context.Context, in *NearestRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FeatureDistance], error)
+	// A server-to-client streaming RPC
+	// Client sends a text query and the Server returns the features whose name
+	// or description contains every query word, best match first
+	SearchFeatures(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SearchResult], error)
 	// A bi-directional streaming RPC that allows both client and server
-	// to receive route notes
+	// to receive route notes. The first note a stream sends at a location joins it:
+	// the server replays that location's history once, then streams only new notes
+	// posted there by others. A note with an empty message joins without posting.
 	RouteChat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RouteNote, RouteNote], error)
+	// Adds a feature. Fails with ALREADY_EXISTS if its location or id is taken.
+	// Rebuilds the catalogue indexes: O(N log N) for N features.
+	CreateFeature(ctx context.Context, in *CreateFeatureRequest, opts ...grpc.CallOption) (*Feature, error)
+	// Renames and/or moves the feature with the given id.
+	// Rebuilds the catalogue indexes: O(N log N) for N features.
+	UpdateFeature(ctx context.Context, in *UpdateFeatureRequest, opts ...grpc.CallOption) (*Feature, error)
+	// Removes the feature with the given id.
+	// Rebuilds the catalogue indexes: O(N log N) for N features.
+	DeleteFeature(ctx context.Context, in *DeleteFeatureRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type routeGuideClient struct {
@@ -64,13 +100,13 @@ func (c *routeGuideClient) GetFeature(ctx context.Context, in *Point, opts ...gr
 	return out, nil
 }
 
-func (c *routeGuideClient) ListFeatures(ctx context.Context, in *Rectangle, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error) {
+func (c *routeGuideClient) ListFeatures(ctx context.Context, in *ListFeaturesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[0], RouteGuide_ListFeatures_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &grpc.GenericClientStream[Rectangle, Feature]{ClientStream: stream}
+	x := &grpc.GenericClientStream[ListFeaturesRequest, Feature]{ClientStream: stream}
 	if err := x.ClientStream.SendMsg(in); err != nil {
 		return nil, err
 	}
@@ -83,9 +119,28 @@ func (c *routeGuideClient) ListFeatures(ctx context.Context, in *Rectangle, opts
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_ListFeaturesClient = grpc.ServerStreamingClient[Feature]
 
+func (c *routeGuideClient) ListFeaturesInRadius(ctx context.Context, in *Circle, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[1], RouteGuide_ListFeaturesInRadius_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Circle, Feature]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_ListFeaturesInRadiusClient = grpc.ServerStreamingClient[Feature]
+
 func (c *routeGuideClient) RecordRoute(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Point, RouteSummary], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[1], RouteGuide_RecordRoute_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[2], RouteGuide_RecordRoute_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -96,9 +151,66 @@ func (c *routeGuideClient) RecordRoute(ctx context.Context, opts ...grpc.CallOpt
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_RecordRouteClient = grpc.ClientStreamingClient[Point, RouteSummary]
 
+func (c *routeGuideClient) ListFeaturesInPolygon(ctx context.Context, in *Polygon, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Feature], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[3], RouteGuide_ListFeaturesInPolygon_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Polygon, Feature]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_ListFeaturesInPolygonClient = grpc.ServerStreamingClient[Feature]
+
+func (c *routeGuideClient) NearestFeatures(ctx context.Context, in *NearestRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FeatureDistance], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[4], RouteGuide_NearestFeatures_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[NearestRequest, FeatureDistance]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_NearestFeaturesClient = grpc.ServerStreamingClient[FeatureDistance]
+
+func (c *routeGuideClient) SearchFeatures(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SearchResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[5], RouteGuide_SearchFeatures_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SearchRequest, SearchResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_SearchFeaturesClient = grpc.ServerStreamingClient[SearchResult]
+
 func (c *routeGuideClient) RouteChat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RouteNote, RouteNote], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[2], RouteGuide_RouteChat_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &RouteGuide_ServiceDesc.Streams[6], RouteGuide_RouteChat_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +221,36 @@ func (c *routeGuideClient) RouteChat(ctx context.Context, opts ...grpc.CallOptio
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_RouteChatClient = grpc.BidiStreamingClient[RouteNote, RouteNote]
 
+func (c *routeGuideClient) CreateFeature(ctx context.Context, in *CreateFeatureRequest, opts ...grpc.CallOption) (*Feature, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Feature)
+	err := c.cc.Invoke(ctx, RouteGuide_CreateFeature_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) UpdateFeature(ctx context.Context, in *UpdateFeatureRequest, opts ...grpc.CallOption) (*Feature, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Feature)
+	err := c.cc.Invoke(ctx, RouteGuide_UpdateFeature_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routeGuideClient) DeleteFeature(ctx context.Context, in *DeleteFeatureRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, RouteGuide_DeleteFeature_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RouteGuideServer is the server API for RouteGuide service.
 // All implementations must embed UnimplementedRouteGuideServer
 // for forward compatibility.
@@ -118,16 +260,44 @@ type RouteGuideServer interface {
 	// Obtains the feature at a given position.
 	GetFeature(context.Context, *Point) (*Feature, error)
 	// A server-to-client streaming RPC
-	// Lists all features within given rectangle. Results are streamed
-	// instead of returned all at once
-	ListFeatures(*Rectangle, grpc.ServerStreamingServer[Feature]) error
+	// Client sends a rectangel and the Server returns all features within given rectangle.
+	// Results are returned as a instead of returned all at once
+	// An optional filter narrows the results on the server.
+	ListFeatures(*ListFeaturesRequest, grpc.ServerStreamingServer[Feature]) error
+	// A server-to-client streaming RPC
+	// Client sends a circle and the Server returns all features within radius_m
+	// metres of its center, boundary included
+	ListFeaturesInRadius(*Circle, grpc.ServerStreamingServer[Feature]) error
 	// A client-to-server streaming RPC
 	// Client sends a stream of points of its route
 	// and server returns a route summary
 	RecordRoute(grpc.ClientStreamingServer[Point, RouteSummary]) error
+	// A server-to-client streaming RPC
+	// Client sends a polygon and the Server returns all features inside it,
+	// boundary included and holes excluded
+	ListFeaturesInPolygon(*Polygon, grpc.ServerStreamingServer[Feature]) error
+	// A server-to-client streaming RPC
+	// Client sends a point and the Server returns up to k features closest to it,
+	// nearest first, each with its great-circle distance
+	NearestFeatures(*NearestRequest, grpc.ServerStreamingServer[FeatureDistance]) error
+	// A server-to-client streaming RPC
+	// Client sends a text query and the Server returns the features whose name
+	// or description contains every query word, best match first
+	SearchFeatures(*SearchRequest, grpc.ServerStreamingServer[SearchResult]) error
 	// A bi-directional streaming RPC that allows both client and server
-	// to receive route notes
+	// to receive route notes. The first note a stream sends at a location joins it:
+	// the server replays that location's history once, then streams only new notes
+	// posted there by others. A note with an empty message joins without posting.
 	RouteChat(grpc.BidiStreamingServer[RouteNote, RouteNote]) error
+	// Adds a feature. Fails with ALREADY_EXISTS if its location or id is taken.
+	// Rebuilds the catalogue indexes: O(N log N) for N features.
+	CreateFeature(context.Context, *CreateFeatureRequest) (*Feature, error)
+	// Renames and/or moves the feature with the given id.
+	// Rebuilds the catalogue indexes: O(N log N) for N features.
+	UpdateFeature(context.Context, *UpdateFeatureRequest) (*Feature, error)
+	// Removes the feature with the given id.
+	// Rebuilds the catalogue indexes: O(N log N) for N features.
+	DeleteFeature(context.Context, *DeleteFeatureRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedRouteGuideServer()
 }
 
@@ -141,15 +311,36 @@ type UnimplementedRouteGuideServer struct{}
 func (UnimplementedRouteGuideServer) GetFeature(context.Context, *Point) (*Feature, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetFeature not implemented")
 }
-func (UnimplementedRouteGuideServer) ListFeatures(*Rectangle, grpc.ServerStreamingServer[Feature]) error {
+func (UnimplementedRouteGuideServer) ListFeatures(*ListFeaturesRequest, grpc.ServerStreamingServer[Feature]) error {
 	return status.Errorf(codes.Unimplemented, "method ListFeatures not implemented")
 }
+func (UnimplementedRouteGuideServer) ListFeaturesInRadius(*Circle, grpc.ServerStreamingServer[Feature]) error {
+	return status.Errorf(codes.Unimplemented, "method ListFeaturesInRadius not implemented")
+}
 func (UnimplementedRouteGuideServer) RecordRoute(grpc.ClientStreamingServer[Point, RouteSummary]) error {
 	return status.Errorf(codes.Unimplemented, "method RecordRoute not implemented")
 }
+func (UnimplementedRouteGuideServer) ListFeaturesInPolygon(*Polygon, grpc.ServerStreamingServer[Feature]) error {
+	return status.Errorf(codes.Unimplemented, "method ListFeaturesInPolygon not implemented")
+}
+func (UnimplementedRouteGuideServer) NearestFeatures(*NearestRequest, grpc.ServerStreamingServer[FeatureDistance]) error {
+	return status.Errorf(codes.Unimplemented, "method NearestFeatures not implemented")
+}
+func (UnimplementedRouteGuideServer) SearchFeatures(*SearchRequest, grpc.ServerStreamingServer[SearchResult]) error {
+	return status.Errorf(codes.Unimplemented, "method SearchFeatures not implemented")
+}
 func (UnimplementedRouteGuideServer) RouteChat(grpc.BidiStreamingServer[RouteNote, RouteNote]) error {
 	return status.Errorf(codes.Unimplemented, "method RouteChat not implemented")
 }
+func (UnimplementedRouteGuideServer) CreateFeature(context.Context, *CreateFeatureRequest) (*Feature, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateFeature not implemented")
+}
+func (UnimplementedRouteGuideServer) UpdateFeature(context.Context, *UpdateFeatureRequest) (*Feature, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateFeature not implemented")
+}
+func (UnimplementedRouteGuideServer) DeleteFeature(context.Context, *DeleteFeatureRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFeature not implemented")
+}
 func (UnimplementedRouteGuideServer) mustEmbedUnimplementedRouteGuideServer() {}
 func (UnimplementedRouteGuideServer) testEmbeddedByValue()                    {}
 
@@ -190,16 +381,27 @@ func _RouteGuide_GetFeature_Handler(srv interface{}, ctx context.Context, dec fu
 }
 
 func _RouteGuide_ListFeatures_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(Rectangle)
+	m := new(ListFeaturesRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(RouteGuideServer).ListFeatures(m, &grpc.GenericServerStream[Rectangle, Feature]{ServerStream: stream})
+	return srv.(RouteGuideServer).ListFeatures(m, &grpc.GenericServerStream[ListFeaturesRequest, Feature]{ServerStream: stream})
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_ListFeaturesServer = grpc.ServerStreamingServer[Feature]
 
+func _RouteGuide_ListFeaturesInRadius_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Circle)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouteGuideServer).ListFeaturesInRadius(m, &grpc.GenericServerStream[Circle, Feature]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_ListFeaturesInRadiusServer = grpc.ServerStreamingServer[Feature]
+
 func _RouteGuide_RecordRoute_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(RouteGuideServer).RecordRoute(&grpc.GenericServerStream[Point, RouteSummary]{ServerStream: stream})
 }
@@ -207,6 +409,39 @@ func _RouteGuide_RecordRoute_Handler(srv interface{}, stream grpc.ServerStream)
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_RecordRouteServer = grpc.ClientStreamingServer[Point, RouteSummary]
 
+func _RouteGuide_ListFeaturesInPolygon_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Polygon)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouteGuideServer).ListFeaturesInPolygon(m, &grpc.GenericServerStream[Polygon, Feature]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_ListFeaturesInPolygonServer = grpc.ServerStreamingServer[Feature]
+
+func _RouteGuide_NearestFeatures_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(NearestRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouteGuideServer).NearestFeatures(m, &grpc.GenericServerStream[NearestRequest, FeatureDistance]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_NearestFeaturesServer = grpc.ServerStreamingServer[FeatureDistance]
+
+func _RouteGuide_SearchFeatures_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouteGuideServer).SearchFeatures(m, &grpc.GenericServerStream[SearchRequest, SearchResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RouteGuide_SearchFeaturesServer = grpc.ServerStreamingServer[SearchResult]
+
 func _RouteGuide_RouteChat_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(RouteGuideServer).RouteChat(&grpc.GenericServerStream[RouteNote, RouteNote]{ServerStream: stream})
 }
@@ -214,6 +449,60 @@ func _RouteGuide_RouteChat_Handler(srv interface{}, stream grpc.ServerStream) er
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type RouteGuide_RouteChatServer = grpc.BidiStreamingServer[RouteNote, RouteNote]
 
+func _RouteGuide_CreateFeature_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateFeatureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).CreateFeature(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_CreateFeature_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).CreateFeature(ctx, req.(*CreateFeatureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_UpdateFeature_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateFeatureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).UpdateFeature(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_UpdateFeature_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).UpdateFeature(ctx, req.(*UpdateFeatureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RouteGuide_DeleteFeature_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFeatureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteGuideServer).DeleteFeature(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RouteGuide_DeleteFeature_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteGuideServer).DeleteFeature(ctx, req.(*DeleteFeatureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RouteGuide_ServiceDesc is the grpc.ServiceDesc for RouteGuide service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -225,6 +514,18 @@ var RouteGuide_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetFeature",
 			Handler:    _RouteGuide_GetFeature_Handler,
 		},
+		{
+			MethodName: "CreateFeature",
+			Handler:    _RouteGuide_CreateFeature_Handler,
+		},
+		{
+			MethodName: "UpdateFeature",
+			Handler:    _RouteGuide_UpdateFeature_Handler,
+		},
+		{
+			MethodName: "DeleteFeature",
+			Handler:    _RouteGuide_DeleteFeature_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -232,11 +533,31 @@ var RouteGuide_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _RouteGuide_ListFeatures_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ListFeaturesInRadius",
+			Handler:       _RouteGuide_ListFeaturesInRadius_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "RecordRoute",
 			Handler:       _RouteGuide_RecordRoute_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "ListFeaturesInPolygon",
+			Handler:       _RouteGuide_ListFeaturesInPolygon_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "NearestFeatures",
+			Handler:       _RouteGuide_NearestFeatures_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SearchFeatures",
+			Handler:       _RouteGuide_SearchFeatures_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "RouteChat",
 			Handler:       _RouteGuide_RouteChat_Handler,
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"maps"
+	pb "routeguide/routeguide"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The admin RPCs never modify the current featureSet or the features in it,
+// since handlers may be reading them. Each write copies the catalogue,
+// applies the change and swaps in a new snapshot under featuresMu. Building
+// the snapshot re-indexes every feature, so each write costs O(N log N) and
+// holds up the writers queued behind it, though never the readers.
+
+// CreateFeature adds a feature to the served catalogue
+func (s *routeGuideServer) CreateFeature(_ context.Context, req *pb.CreateFeatureRequest) (*pb.Feature, error) {
+	if err := validateCreateFeatureRequest(req); err != nil {
+		return nil, err
+	}
+	feature := proto.Clone(req.Feature).(*pb.Feature)
+	if feature.Id == "" {
+		feature.Id = newFeatureID()
+	}
+	feature.CreatedAt = timestamppb.Now()
+	feature.UpdatedAt = feature.CreatedAt
+
+	s.featuresMu.Lock()
+	defer s.featuresMu.Unlock()
+	current := s.snapshot()
+	if err := checkLocationFree(current, feature); err != nil {
+		return nil, err
+	}
+	if _, ok := current.byID[feature.Id]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "a feature with id %q already exists", feature.Id)
+	}
+
+	features := make([]*pb.Feature, 0, len(current.features)+1)
+	features = append(features, current.features...)
+	s.features.Store(newFeatureSet(append(features, feature)))
+	slog.Info("created feature", "id", feature.Id, "name", feature.Name)
+	return feature, nil
+}
+
+// UpdateFeature changes the fields of an existing feature selected by the
+// update mask, keeping its id and creation time
+func (s *routeGuideServer) UpdateFeature(_ context.Context, req *pb.UpdateFeatureRequest) (*pb.Feature, error) {
+	if err := validateUpdateFeatureRequest(req); err != nil {
+		return nil, err
+	}
+	paths := req.UpdateMask.GetPaths()
+	if len(paths) == 0 {
+		paths = featureFields
+	}
+
+	s.featuresMu.Lock()
+	defer s.featuresMu.Unlock()
+	current := s.snapshot()
+	existing, ok := current.byID[req.Feature.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no feature with id %q", req.Feature.Id)
+	}
+
+	updated := proto.Clone(existing).(*pb.Feature)
+	for _, path := range paths {
+		switch path {
+		case "name":
+			updated.Name = req.Feature.Name
+		case "location":
+			updated.Location = proto.Clone(req.Feature.Location).(*pb.Point)
+		case "category":
+			updated.Category = req.Feature.Category
+		case "tags":
+			updated.Tags = maps.Clone(req.Feature.Tags)
+		case "description":
+			updated.Description = req.Feature.Description
+		case "elevation_m":
+			updated.ElevationM = req.Feature.ElevationM
+		}
+	}
+	updated.UpdatedAt = timestamppb.Now()
+	if !samePoint(existing.Location, updated.Location) {
+		if err := checkLocationFree(current, updated); err != nil {
+			return nil, err
+		}
+	}
+
+	features := slices.Clone(current.features)
+	features[slices.Index(features, existing)] = updated
+	s.features.Store(newFeatureSet(features))
+	slog.Info("updated feature", "id", updated.Id, "name", updated.Name)
+	return updated, nil
+}
+
+// DeleteFeature removes a feature from the served catalogue
+func (s *routeGuideServer) DeleteFeature(_ context.Context, req *pb.DeleteFeatureRequest) (*emptypb.Empty, error) {
+	if err := validateDeleteFeatureRequest(req); err != nil {
+		return nil, err
+	}
+
+	s.featuresMu.Lock()
+	defer s.featuresMu.Unlock()
+	current := s.snapshot()
+	existing, ok := current.byID[req.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no feature with id %q", req.Id)
+	}
+
+	features := make([]*pb.Feature, 0, len(current.features)-1)
+	for _, feature := range current.features {
+		if feature != existing {
+			features = append(features, feature)
+		}
+	}
+	s.features.Store(newFeatureSet(features))
+	slog.Info("deleted feature", "id", existing.Id, "name", existing.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// checkLocationFree fails with codes.AlreadyExists if another feature
+// already occupies feature's location
+func checkLocationFree(fs *featureSet, feature *pb.Feature) error {
+	if other := fs.findFeatureAtPoint(feature.Location); other != nil && other.Id != feature.Id {
+		return status.Errorf(codes.AlreadyExists, "feature %s (%q) already exists at (%d, %d)",
+			other.Id, other.Name, feature.Location.Latitude, feature.Location.Longitude)
+	}
+	return nil
+}
+
+// newFeatureID returns a random id for a feature created over the API
+func newFeatureID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys callers authenticate with
+const (
+	authorizationKey = "authorization" // "Bearer <jwt>"
+	apiKeyKey        = "x-api-key"
+)
+
+// identity is the authenticated caller of an RPC
+type identity struct {
+	subject string   // API key owner or the JWT "sub" claim
+	method  string   // "api-key" or "jwt"
+	roles   []string // from the API key file or the JWT "roles" claim
+}
+
+type identityKey struct{}
+
+func withIdentity(ctx context.Context, id *identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// identityFromContext returns the caller the auth interceptors attached to
+// ctx, or nil when authentication is disabled or the caller is anonymous
+func identityFromContext(ctx context.Context) *identity {
+	id, _ := ctx.Value(identityKey{}).(*identity)
+	return id
+}
+
+// authenticator checks the credentials sent with every RPC. Each request
+// must carry either a known API key or a valid JWT, unless anonymous calls
+// are allowed; invalid credentials are always rejected.
+type authenticator struct {
+	apiKeys   map[[sha256.Size]byte]*identity // caller by SHA-256 of the key
+	jwt       *jwtVerifier                    // nil if JWTs are not accepted
+	anonymous bool                            // let calls without credentials through
+}
+
+// newAuthenticator loads the credentials named by the auth settings
+func newAuthenticator(cfg *config) (*authenticator, error) {
+	a := &authenticator{}
+	if cfg.apiKeys != "" {
+		keys, err := loadAPIKeys(cfg.apiKeys)
+		if err != nil {
+			return nil, err
+		}
+		a.apiKeys = keys
+	}
+	if cfg.jwtSecretFile != "" || cfg.jwksFile != "" {
+		a.jwt = &jwtVerifier{issuer: cfg.jwtIssuer, audience: cfg.jwtAudience}
+		if cfg.jwtSecretFile != "" {
+			secret, err := loadHMACSecret(cfg.jwtSecretFile)
+			if err != nil {
+				return nil, err
+			}
+			a.jwt.hmacSecret = secret
+		}
+		if cfg.jwksFile != "" {
+			keys, err := loadJWKS(cfg.jwksFile)
+			if err != nil {
+				return nil, err
+			}
+			a.jwt.rsaKeys = keys
+		}
+	}
+	return a, nil
+}
+
+// loadAPIKeys reads a JSON array of {"key": ..., "subject": ..., "roles": [...]}
+// objects
+func loadAPIKeys(path string) (map[[sha256.Size]byte]*identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Key     string   `json:"key"`
+		Subject string   `json:"subject"`
+		Roles   []string `json:"roles"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	keys := make(map[[sha256.Size]byte]*identity, len(entries))
+	for i, entry := range entries {
+		if entry.Key == "" || entry.Subject == "" {
+			return nil, fmt.Errorf("%s: [%d]: key and subject are required", path, i)
+		}
+		hash := sha256.Sum256([]byte(entry.Key))
+		if _, dup := keys[hash]; dup {
+			return nil, fmt.Errorf("%s: [%d]: duplicate key", path, i)
+		}
+		keys[hash] = &identity{subject: entry.Subject, method: "api-key", roles: entry.Roles}
+	}
+	return keys, nil
+}
+
+// authenticate returns the caller identified by the request metadata, or nil
+// for an allowed anonymous call
+func (a *authenticator) authenticate(ctx context.Context) (*identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get(apiKeyKey); len(keys) > 0 && a.apiKeys != nil {
+		// Keys are looked up by hash so that lookup time reveals nothing
+		// about how close a guess came to a real key
+		if id, ok := a.apiKeys[sha256.Sum256([]byte(keys[0]))]; ok {
+			return id, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "unknown API key")
+	}
+	if values := md.Get(authorizationKey); len(values) > 0 && a.jwt != nil {
+		token, ok := strings.CutPrefix(values[0], "Bearer ")
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "authorization must be a Bearer token")
+		}
+		claims, err := a.jwt.verify(strings.TrimSpace(token), time.Now())
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+		}
+		return &identity{subject: claims.Subject, method: "jwt", roles: claims.Roles}, nil
+	}
+	if a.anonymous {
+		return nil, nil
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing credentials")
+}
+
+func (a *authenticator) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id, err := a.authenticate(ctx)
+	if err != nil {
+		slog.Debug("rejected unauthenticated call", "method", info.FullMethod, "err", err)
+		return nil, err
+	}
+	return handler(withIdentity(ctx, id), req)
+}
+
+func (a *authenticator) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	id, err := a.authenticate(ss.Context())
+	if err != nil {
+		slog.Debug("rejected unauthenticated call", "method", info.FullMethod, "err", err)
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: withIdentity(ss.Context(), id)})
+}
+
+// contextStream is a ServerStream whose Context has been replaced
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
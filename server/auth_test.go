@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	pb "routeguide/routeguide"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testAuthenticator accepts the API key "alice-key" for alice and HS256
+// tokens signed with testHMACSecret
+func testAuthenticator() *authenticator {
+	return &authenticator{
+		apiKeys: map[[sha256.Size]byte]*identity{
+			sha256.Sum256([]byte("alice-key")): {subject: "alice", method: "api-key", roles: []string{"writer"}},
+		},
+		jwt: &jwtVerifier{hmacSecret: testHMACSecret},
+	}
+}
+
+// testToken returns an HS256 token for subject valid for the next hour
+func testToken(t *testing.T, subject string) string {
+	t.Helper()
+	return signHS256(t, testHMACSecret, map[string]any{"alg": "HS256"}, map[string]any{
+		"sub":   subject,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"reader"},
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name      string
+		md        []string // metadata key/value pairs
+		anonymous bool
+		want      string // subject, or empty for an anonymous call
+		wantErr   bool
+	}{
+		{"API key", []string{apiKeyKey, "alice-key"}, false, "alice", false},
+		{"unknown API key", []string{apiKeyKey, "mallory-key"}, false, "", true},
+		{"empty API key", []string{apiKeyKey, ""}, false, "", true},
+		{"bearer token", []string{authorizationKey, "Bearer " + testToken(t, "bob")}, false, "bob", false},
+		{"token without Bearer", []string{authorizationKey, testToken(t, "bob")}, false, "", true},
+		{"basic auth", []string{authorizationKey, "Basic YWxpY2U6c2VjcmV0"}, false, "", true},
+		{"invalid token", []string{authorizationKey, "Bearer " + testToken(t, "bob") + "x"}, false, "", true},
+		{"both, API key valid", []string{apiKeyKey, "alice-key", authorizationKey, "Bearer " + testToken(t, "bob")}, false, "alice", false},
+		{"both, API key unknown", []string{apiKeyKey, "mallory-key", authorizationKey, "Bearer " + testToken(t, "bob")}, false, "", true},
+		{"unknown API key when anonymous calls are allowed", []string{apiKeyKey, "mallory-key"}, true, "", true},
+		{"no credentials", nil, false, "", true},
+		{"no credentials when anonymous calls are allowed", nil, true, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := testAuthenticator()
+			a.anonymous = tt.anonymous
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tt.md...))
+			id, err := a.authenticate(ctx)
+			if tt.wantErr {
+				if status.Code(err) != codes.Unauthenticated {
+					t.Fatalf("authenticate: %v, want Unauthenticated", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate: %v", err)
+			}
+			var subject string
+			if id != nil {
+				subject = id.subject
+			}
+			if subject != tt.want {
+				t.Errorf("authenticated as %q, want %q", subject, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRoles(t *testing.T) {
+	a := testAuthenticator()
+	for md, want := range map[string][]string{
+		apiKeyKey:        {"writer"},
+		authorizationKey: {"reader"},
+	} {
+		value := "alice-key"
+		if md == authorizationKey {
+			value = "Bearer " + testToken(t, "bob")
+		}
+		id, err := a.authenticate(metadata.NewIncomingContext(context.Background(), metadata.Pairs(md, value)))
+		if err != nil {
+			t.Fatalf("%s: %v", md, err)
+		}
+		if !slices.Equal(id.roles, want) {
+			t.Errorf("%s: roles %v, want %v", md, id.roles, want)
+		}
+	}
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	a := testAuthenticator()
+	client := newTestClient(t, grpc.ChainUnaryInterceptor(a.unaryInterceptor), grpc.ChainStreamInterceptor(a.streamInterceptor))
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), apiKeyKey, key)
+	}
+	rect := &pb.ListFeaturesRequest{BottomLeftCorner: pt(-90e7, -180e7), TopRightCorner: pt(90e7, 180e7)}
+
+	if _, err := client.GetFeature(context.Background(), pt(0, 0)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("unary call without credentials: %v, want Unauthenticated", err)
+	}
+	if _, err := client.GetFeature(withKey("mallory-key"), pt(0, 0)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("unary call with an unknown key: %v, want Unauthenticated", err)
+	}
+	if _, err := client.GetFeature(withKey("alice-key"), pt(0, 0)); err != nil {
+		t.Errorf("unary call with a valid key: %v", err)
+	}
+
+	stream, err := client.ListFeatures(context.Background(), rect)
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("streaming call without credentials: %v, want Unauthenticated", err)
+	}
+	stream, err = client.ListFeatures(withKey("alice-key"), rect)
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if err != nil && err != io.EOF {
+		t.Errorf("streaming call with a valid key: %v", err)
+	}
+}
+
+// Notes posted over RouteChat carry the authenticated subject, whatever
+// author the client claims
+func TestRouteChatAuthor(t *testing.T) {
+	a := testAuthenticator()
+	client := newTestClient(t, grpc.ChainStreamInterceptor(a.streamInterceptor))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), authorizationKey, "Bearer "+testToken(t, "bob"))
+	stream, err := client.RouteChat(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&pb.RouteNote{Location: pt(1, 2), Message: "hello", Author: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	stream.CloseSend()
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+
+	reader, err := client.RouteChat(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Send(&pb.RouteNote{Location: pt(1, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	note, err := reader.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.Author != "bob" {
+		t.Errorf("note author %q, want bob", note.Author)
+	}
+	reader.CloseSend()
+}
+
+func TestLoadHMACSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"long enough", strings.Repeat("s", minHMACSecretLength), false},
+		{"trailing newline", strings.Repeat("s", minHMACSecretLength) + "\n", false},
+		{"empty", "", true},
+		{"whitespace", strings.Repeat(" \n", minHMACSecretLength), true},
+		{"one byte short", strings.Repeat("s", minHMACSecretLength-1), true},
+		{"short with padding", strings.Repeat("s", minHMACSecretLength-1) + "\n\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secret")
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			secret, err := loadHMACSecret(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("accepted a %d-byte secret", len(secret))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadHMACSecret: %v", err)
+			}
+			if strings.TrimSpace(tt.data) != string(secret) {
+				t.Errorf("secret %q, want the file's contents without surrounding whitespace", secret)
+			}
+		})
+	}
+}
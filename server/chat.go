@@ -0,0 +1,113 @@
+package main
+
+import (
+	pb "routeguide/routeguide"
+	"sync"
+)
+
+// chatQueueLimit is how many notes may queue up for a RouteChat stream
+// before it is considered too slow and disconnected
+const chatQueueLimit = 1024
+
+// chatSubscriber is one open RouteChat stream. Notes for it are appended to
+// queue while the server's mu is held, which keeps history and live notes in
+// order, and written out by the stream's own handler goroutine, so a slow
+// client only ever backs up its own queue.
+type chatSubscriber struct {
+	mu      sync.Mutex
+	queue   []*pb.RouteNote // notes waiting to be sent
+	ready   chan struct{}   // holds a token while queue is non-empty
+	evicted chan struct{}   // closed when the queue overflowed
+	once    sync.Once       // guards closing evicted
+	keys    map[string]bool // locations this stream has joined; guarded by the server's mu
+	left    bool            // set once the stream has left; guarded by the server's mu
+}
+
+func newChatSubscriber() *chatSubscriber {
+	return &chatSubscriber{
+		ready:   make(chan struct{}, 1),
+		evicted: make(chan struct{}),
+		keys:    make(map[string]bool),
+	}
+}
+
+// push queues notes unconditionally; used for the subscriber's own history replay
+func (sub *chatSubscriber) push(notes ...*pb.RouteNote) {
+	if len(notes) == 0 {
+		return
+	}
+	sub.mu.Lock()
+	sub.queue = append(sub.queue, notes...)
+	sub.mu.Unlock()
+	sub.signal()
+}
+
+// offer queues a note relayed from another stream. A subscriber whose queue
+// is full is evicted rather than allowed to grow without bound.
+func (sub *chatSubscriber) offer(note *pb.RouteNote) {
+	sub.mu.Lock()
+	if len(sub.queue) >= chatQueueLimit {
+		sub.mu.Unlock()
+		sub.once.Do(func() { close(sub.evicted) })
+		return
+	}
+	sub.queue = append(sub.queue, note)
+	sub.mu.Unlock()
+	sub.signal()
+}
+
+func (sub *chatSubscriber) signal() {
+	select {
+	case sub.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take removes and returns everything queued so far
+func (sub *chatSubscriber) take() []*pb.RouteNote {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	notes := sub.queue
+	sub.queue = nil
+	return notes
+}
+
+// join subscribes sub to the location key and queues the location's history
+// after resumeAfter. It does nothing if sub already joined key, or has left:
+// the receiving goroutine can still be joining after the handler returned.
+// The caller must hold s.mu.
+func (s *routeGuideServer) join(sub *chatSubscriber, key string, resumeAfter uint64) {
+	if sub.left || sub.keys[key] {
+		return
+	}
+	sub.keys[key] = true
+	if s.subscribers[key] == nil {
+		s.subscribers[key] = make(map[*chatSubscriber]struct{})
+	}
+	s.subscribers[key][sub] = struct{}{}
+
+	sub.push(s.notes.history(key, resumeAfter)...)
+}
+
+// leave removes sub from every location it joined and stops it joining any more
+func (s *routeGuideServer) leave(sub *chatSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub.left = true
+	for key := range sub.keys {
+		delete(s.subscribers[key], sub)
+		if len(s.subscribers[key]) == 0 {
+			delete(s.subscribers, key)
+		}
+	}
+}
+
+// broadcast offers note to every subscriber at key except from.
+// The caller must hold s.mu.
+func (s *routeGuideServer) broadcast(from *chatSubscriber, key string, note *pb.RouteNote) {
+	for sub := range s.subscribers[key] {
+		if sub != from {
+			sub.offer(note)
+		}
+	}
+}
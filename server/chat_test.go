@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+// The handler can return and leave while its receiving goroutine is still
+// processing a note; the join that follows must not subscribe the stream again
+func TestJoinAfterLeave(t *testing.T) {
+	s := newServer(demoFeatures(), newMemoryNoteStore(retentionPolicy{}))
+	sub := newChatSubscriber()
+	s.mu.Lock()
+	s.join(sub, "1,2", 0)
+	s.mu.Unlock()
+	s.leave(sub)
+
+	s.mu.Lock()
+	s.join(sub, "3,4", 0)
+	s.mu.Unlock()
+	if len(s.subscribers) != 0 {
+		t.Errorf("subscribers left behind: %v", s.subscribers)
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// envPrefix starts the environment variable for each setting, e.g.
+// ROUTEGUIDE_NOTES_TTL for -notes-ttl
+const envPrefix = "ROUTEGUIDE_"
+
+// config holds every server setting. Each setting is a flag, and can also be
+// given in a JSON config file under the flag's name or in the environment as
+// ROUTEGUIDE_<NAME>, with dashes as underscores. Flags override the
+// environment, which overrides the file, which overrides the defaults.
+type config struct {
+	configFile string
+
+	listen   string // host:port, or unix:<path> for a Unix domain socket
+	features string
+	watch    time.Duration
+
+	maxRecvMsgSize   int
+	maxSendMsgSize   int
+	keepaliveTime    time.Duration
+	keepaliveTimeout time.Duration
+	keepaliveMinTime time.Duration
+
+	tlsCert     string
+	tlsKey      string
+	tlsClientCA string
+
+	apiKeys       string
+	jwtSecretFile string
+	jwksFile      string
+	jwtIssuer     string
+	jwtAudience   string
+	authPolicy    string
+
+	rateLimit      float64
+	rateBurst      int
+	maxStreams     int
+	streamMsgRate  float64
+	streamMsgBurst int
+
+	logLevel  slog.Level
+	logFormat string
+	debugAddr string
+
+	notesPerLocation   int
+	notesMax           int
+	notesTTL           time.Duration
+	janitorInterval    time.Duration
+	notesDir           string
+	notesFsync         string
+	notesFsyncInterval time.Duration
+	notesSnapshotEvery int
+}
+
+// register defines a flag for every setting, with its default
+func (c *config) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.configFile, "config", "", "JSON file of settings keyed by flag name, e.g. {\"listen\": \":8080\", \"notes-ttl\": \"1h\"}")
+
+	fs.StringVar(&c.listen, "listen", ":50051", "address to serve on: host:port, or unix:<path> for a Unix domain socket")
+	fs.StringVar(&c.features, "features", "", "JSON or GeoJSON file of features to serve; the built-in demo landmarks are used if empty")
+	fs.DurationVar(&c.watch, "watch", 5*time.Second, "how often to check the features file for changes; 0 disables file watching (SIGHUP still reloads)")
+
+	fs.IntVar(&c.maxRecvMsgSize, "max-recv-msg-size", 4<<20, "largest request message the server accepts, in bytes")
+	fs.IntVar(&c.maxSendMsgSize, "max-send-msg-size", 4<<20, "largest response message the server sends, in bytes")
+	fs.DurationVar(&c.keepaliveTime, "keepalive-time", 2*time.Hour, "ping a client after this long without activity")
+	fs.DurationVar(&c.keepaliveTimeout, "keepalive-timeout", 20*time.Second, "close the connection if a keepalive ping is not answered within this long")
+	fs.DurationVar(&c.keepaliveMinTime, "keepalive-min-time", 5*time.Minute, "close connections whose clients ping more often than this")
+
+	fs.StringVar(&c.tlsCert, "tls-cert", "", "PEM certificate file; serves TLS when set together with -tls-key. Reloaded when it changes.")
+	fs.StringVar(&c.tlsKey, "tls-key", "", "PEM private key file for -tls-cert")
+	fs.StringVar(&c.tlsClientCA, "tls-client-ca", "", "PEM file of CAs for mutual TLS; clients must present a certificate they signed")
+
+	fs.StringVar(&c.apiKeys, "api-keys", "", "JSON file of API keys, [{\"key\": ..., \"subject\": ...}], accepted in x-api-key metadata; enables authentication")
+	fs.StringVar(&c.jwtSecretFile, "jwt-hmac-secret-file", "", "file holding the shared secret for HS256 bearer tokens; enables authentication")
+	fs.StringVar(&c.jwksFile, "jwks-file", "", "JWKS file of RSA public keys for RS256 bearer tokens; enables authentication")
+	fs.StringVar(&c.jwtIssuer, "jwt-issuer", "", "if set, bearer tokens must carry this iss claim")
+	fs.StringVar(&c.jwtAudience, "jwt-audience", "", "if set, bearer tokens must list this audience in their aud claim")
+	fs.StringVar(&c.authPolicy, "auth-policy", "", "JSON file mapping roles to the methods they may call; without it any authenticated caller may call any method")
+
+	fs.Float64Var(&c.rateLimit, "rate-limit", 0, "calls per second each caller may start per method, by identity or else IP address; 0 for no limit")
+	fs.IntVar(&c.rateBurst, "rate-burst", 100, "calls a caller may start at once per method before -rate-limit applies")
+	fs.IntVar(&c.maxStreams, "max-streams", 0, "streaming calls each caller may have open at once; 0 for no limit")
+	fs.Float64Var(&c.streamMsgRate, "stream-msg-rate", 0, "messages per second a client may send on each RecordRoute or RouteChat stream; 0 for no limit")
+	fs.IntVar(&c.streamMsgBurst, "stream-msg-burst", 200, "messages a client may send at once on a stream before -stream-msg-rate applies")
+
+	fs.TextVar(&c.logLevel, "log-level", slog.LevelInfo, "minimum level to log: debug, info, warn or error")
+	fs.StringVar(&c.logFormat, "log-format", "text", "log output format: text or json")
+	fs.StringVar(&c.debugAddr, "debug-addr", "", "if set, serve expvar metrics such as note eviction counts at http://<addr>/debug/vars")
+
+	fs.IntVar(&c.notesPerLocation, "notes-per-location", 1000, "maximum route notes kept at one location; 0 for no limit")
+	fs.IntVar(&c.notesMax, "notes-max", 100000, "maximum route notes kept across all locations; 0 for no limit")
+	fs.DurationVar(&c.notesTTL, "notes-ttl", 24*time.Hour, "how long route notes are kept; 0 keeps them until evicted by the caps")
+	fs.DurationVar(&c.janitorInterval, "janitor-interval", time.Minute, "how often expired route notes are removed")
+	fs.StringVar(&c.notesDir, "notes-dir", "", "directory for the durable route note log and snapshots; notes are kept only in memory if empty")
+	fs.StringVar(&c.notesFsync, "notes-fsync", "interval", "when to fsync the route note log: always, interval or never")
+	fs.DurationVar(&c.notesFsyncInterval, "notes-fsync-interval", time.Second, "how often the route note log is fsynced with -notes-fsync=interval")
+	fs.IntVar(&c.notesSnapshotEvery, "notes-snapshot-every", 10000, "route notes appended between snapshots that compact the log; 0 disables snapshots")
+}
+
+// loadConfig reads the settings from args, the environment and the config
+// file, and validates them
+func loadConfig(fs *flag.FlagSet, args []string) (*config, error) {
+	c := &config{}
+	c.register(fs)
+
+	// The first pass only finds -config; the flags are parsed again at the
+	// end so that they override the file and the environment
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if c.configFile == "" {
+		c.configFile = os.Getenv(envName("config"))
+	}
+	if c.configFile != "" {
+		if err := applyConfigFile(fs, c.configFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(fs); err != nil {
+		return nil, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return c, c.validate()
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyConfigFile sets the flags named in a JSON object. Values may be
+// strings in flag syntax, numbers or booleans.
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		f := fs.Lookup(name)
+		if f == nil || name == "config" {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, name))
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(settings[name], &value); err != nil {
+			value = string(settings[name])
+		}
+		if err := f.Value.Set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %v", path, name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyEnv sets every flag that has a ROUTEGUIDE_* environment variable
+func applyEnv(fs *flag.FlagSet) error {
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		if value, ok := os.LookupEnv(name); ok && f.Name != "config" {
+			if err := f.Value.Set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// validate reports every invalid setting at once, one per line
+func (c *config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	network, addr := c.listenAddress()
+	if network == "unix" {
+		check(addr != "", "listen: unix socket path is empty")
+	} else if _, port, err := net.SplitHostPort(addr); err != nil {
+		check(false, "listen: %q is not host:port or unix:<path>", c.listen)
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		check(false, "listen: invalid port %q", port)
+	}
+
+	check(c.maxRecvMsgSize > 0, "max-recv-msg-size: must be positive, got %d", c.maxRecvMsgSize)
+	check(c.maxSendMsgSize > 0, "max-send-msg-size: must be positive, got %d", c.maxSendMsgSize)
+	check(c.keepaliveTime > 0, "keepalive-time: must be positive, got %v", c.keepaliveTime)
+	check(c.keepaliveTimeout > 0, "keepalive-timeout: must be positive, got %v", c.keepaliveTimeout)
+	check(c.keepaliveMinTime >= 0, "keepalive-min-time: must not be negative, got %v", c.keepaliveMinTime)
+
+	check((c.tlsCert == "") == (c.tlsKey == ""), "tls-cert and tls-key: must be set together")
+	check(c.tlsClientCA == "" || c.tlsCert != "", "tls-client-ca: requires tls-cert and tls-key")
+	for _, setting := range []struct{ name, path string }{{"tls-cert", c.tlsCert}, {"tls-key", c.tlsKey}, {"tls-client-ca", c.tlsClientCA}} {
+		if setting.path != "" {
+			_, err := os.Stat(setting.path)
+			check(err == nil, "%s: %v", setting.name, err)
+		}
+	}
+	for _, setting := range []struct{ name, path string }{{"api-keys", c.apiKeys}, {"jwks-file", c.jwksFile}, {"auth-policy", c.authPolicy}} {
+		if setting.path != "" {
+			_, err := os.Stat(setting.path)
+			check(err == nil, "%s: %v", setting.name, err)
+		}
+	}
+	if c.jwtSecretFile != "" {
+		// An empty or short secret would let anyone forge tokens
+		_, err := loadHMACSecret(c.jwtSecretFile)
+		check(err == nil, "jwt-hmac-secret-file: %v", err)
+	}
+	check(c.jwtIssuer == "" && c.jwtAudience == "" || c.jwtSecretFile != "" || c.jwksFile != "",
+		"jwt-issuer and jwt-audience: require jwt-hmac-secret-file or jwks-file")
+	check(c.authPolicy == "" || c.authEnabled(), "auth-policy: requires api-keys, jwt-hmac-secret-file or jwks-file")
+
+	check(c.rateLimit >= 0, "rate-limit: must not be negative, got %v", c.rateLimit)
+	check(c.rateLimit == 0 || c.rateBurst >= 1, "rate-burst: must be at least 1, got %d", c.rateBurst)
+	check(c.maxStreams >= 0, "max-streams: must not be negative, got %d", c.maxStreams)
+	check(c.streamMsgRate >= 0, "stream-msg-rate: must not be negative, got %v", c.streamMsgRate)
+	check(c.streamMsgRate == 0 || c.streamMsgBurst >= 1, "stream-msg-burst: must be at least 1, got %d", c.streamMsgBurst)
+
+	check(c.logFormat == "text" || c.logFormat == "json", "log-format: must be text or json, got %q", c.logFormat)
+
+	check(c.watch >= 0, "watch: must not be negative, got %v", c.watch)
+	check(c.notesPerLocation >= 0, "notes-per-location: must not be negative, got %d", c.notesPerLocation)
+	check(c.notesMax >= 0, "notes-max: must not be negative, got %d", c.notesMax)
+	check(c.notesTTL >= 0, "notes-ttl: must not be negative, got %v", c.notesTTL)
+	check(c.janitorInterval > 0, "janitor-interval: must be positive, got %v", c.janitorInterval)
+	_, err := parseFsyncPolicy(c.notesFsync)
+	check(err == nil, "notes-fsync: %v", err)
+	check(c.notesFsyncInterval > 0, "notes-fsync-interval: must be positive, got %v", c.notesFsyncInterval)
+	check(c.notesSnapshotEvery >= 0, "notes-snapshot-every: must not be negative, got %d", c.notesSnapshotEvery)
+	return errors.Join(errs...)
+}
+
+// listenAddress splits the listen setting into a network and an address
+func (c *config) listenAddress() (network, addr string) {
+	if path, ok := strings.CutPrefix(c.listen, "unix:"); ok {
+		return "unix", path
+	}
+	return "tcp", c.listen
+}
+
+// listener opens the configured listen address. A Unix socket left behind by
+// a previous run is removed first.
+func (c *config) listener() (net.Listener, error) {
+	network, addr := c.listenAddress()
+	if network == "unix" {
+		if info, err := os.Lstat(addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(addr)
+		}
+	}
+	return net.Listen(network, addr)
+}
+
+// serverOptions translates the settings into gRPC server options
+func (c *config) serverOptions() ([]grpc.ServerOption, error) {
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(c.maxRecvMsgSize),
+		grpc.MaxSendMsgSize(c.maxSendMsgSize),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    c.keepaliveTime,
+			Timeout: c.keepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime: c.keepaliveMinTime,
+			// RouteChat listeners can sit idle between notes
+			PermitWithoutStream: true,
+		}),
+	}
+	if c.tlsCert != "" {
+		tlsConfig, err := c.serverTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	unary, stream, err := c.interceptors()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	return opts, nil
+}
+
+// authEnabled reports whether any credentials are configured
+func (c *config) authEnabled() bool {
+	return c.apiKeys != "" || c.jwtSecretFile != "" || c.jwksFile != ""
+}
+
+// limitsEnabled reports whether any rate or concurrency limit is set
+func (c *config) limitsEnabled() bool {
+	return c.rateLimit > 0 || c.maxStreams > 0 || c.streamMsgRate > 0
+}
+
+// interceptors returns the checks every call passes through, in order:
+// authentication, then the rate and concurrency limits, which count calls
+// by the authenticated identity, then the -auth-policy
+func (c *config) interceptors() ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor, error) {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+
+	var policy *accessPolicy
+	if c.authEnabled() {
+		auth, err := newAuthenticator(c)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading credentials: %w", err)
+		}
+		if c.authPolicy != "" {
+			if policy, err = loadPolicy(c.authPolicy); err != nil {
+				return nil, nil, fmt.Errorf("loading auth policy: %w", err)
+			}
+			// The policy decides which methods callers without credentials may use
+			auth.anonymous = true
+		}
+		if c.tlsCert == "" {
+			slog.Warn("authentication is enabled without TLS; credentials are sent in plaintext")
+		}
+		unary = append(unary, auth.unaryInterceptor)
+		stream = append(stream, auth.streamInterceptor)
+	}
+
+	if c.limitsEnabled() {
+		limits := newLimiter(c)
+		unary = append(unary, limits.unaryInterceptor)
+		stream = append(stream, limits.streamInterceptor)
+	}
+
+	if policy != nil {
+		unary = append(unary, policy.unaryInterceptor)
+		stream = append(stream, policy.streamInterceptor)
+	}
+	return unary, stream, nil
+}
+
+// logger returns the logger for the configured level and format
+func (c *config) logger() *slog.Logger {
+	opts := &slog.HandlerOptions{Level: c.logLevel}
+	if c.logFormat == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
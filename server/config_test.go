@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLoadConfig runs loadConfig on a fresh flag set, with the settings in
+// file (if any) written to a temp config file named by -config
+func testLoadConfig(t *testing.T, file string, args ...string) (*config, error) {
+	t.Helper()
+	if file != "" {
+		path := filepath.Join(t.TempDir(), "routeguide.json")
+		writeFile(t, path, []byte(file))
+		args = append([]string{"-config", path}, args...)
+	}
+	fs := flag.NewFlagSet("routeguide", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return loadConfig(fs, args)
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{name: "default", want: ":50051"},
+		{name: "file", file: `{"listen": ":1001"}`, want: ":1001"},
+		{name: "environment", env: map[string]string{"ROUTEGUIDE_LISTEN": ":1002"}, want: ":1002"},
+		{name: "flag", args: []string{"-listen", ":1003"}, want: ":1003"},
+		{
+			name: "environment over file",
+			file: `{"listen": ":1001"}`,
+			env:  map[string]string{"ROUTEGUIDE_LISTEN": ":1002"},
+			want: ":1002",
+		},
+		{
+			name: "flag over environment and file",
+			file: `{"listen": ":1001"}`,
+			env:  map[string]string{"ROUTEGUIDE_LISTEN": ":1002"},
+			args: []string{"-listen", ":1003"},
+			want: ":1003",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			c, err := testLoadConfig(t, tt.file, tt.args...)
+			if err != nil {
+				t.Fatalf("loadConfig: %v", err)
+			}
+			if c.listen != tt.want {
+				t.Errorf("listen = %q, want %q", c.listen, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigSources(t *testing.T) {
+	// Each source can set flags of every type; numbers and booleans in the
+	// file may be JSON values or strings in flag syntax
+	t.Setenv("ROUTEGUIDE_NOTES_TTL", "90m")
+	t.Setenv("ROUTEGUIDE_STREAM_MSG_RATE", "2.5")
+	c, err := testLoadConfig(t, `{"max-streams": 7, "rate-limit": "1.5", "notes-fsync": "always"}`)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if c.maxStreams != 7 || c.rateLimit != 1.5 || c.notesFsync != "always" {
+		t.Errorf("file gave max-streams %d, rate-limit %v, notes-fsync %q; want 7, 1.5, always", c.maxStreams, c.rateLimit, c.notesFsync)
+	}
+	if c.notesTTL != 90*time.Minute || c.streamMsgRate != 2.5 {
+		t.Errorf("environment gave notes-ttl %v, stream-msg-rate %v; want 1h30m0s, 2.5", c.notesTTL, c.streamMsgRate)
+	}
+
+	// The config file itself may be named in the environment
+	path := filepath.Join(t.TempDir(), "routeguide.json")
+	writeFile(t, path, []byte(`{"log-format": "json"}`))
+	t.Setenv("ROUTEGUIDE_CONFIG", path)
+	if c, err := testLoadConfig(t, ""); err != nil || c.logFormat != "json" {
+		t.Errorf("with ROUTEGUIDE_CONFIG: log-format %q, err %v; want json", c.logFormat, err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct{ flag, want string }{
+		{"listen", "ROUTEGUIDE_LISTEN"},
+		{"notes-ttl", "ROUTEGUIDE_NOTES_TTL"},
+		{"max-recv-msg-size", "ROUTEGUIDE_MAX_RECV_MSG_SIZE"},
+		{"jwt-hmac-secret-file", "ROUTEGUIDE_JWT_HMAC_SECRET_FILE"},
+	}
+	for _, tt := range tests {
+		if got := envName(tt.flag); got != tt.want {
+			t.Errorf("envName(%q) = %q, want %q", tt.flag, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want []string // substrings of the error
+	}{
+		{name: "unknown flag", args: []string{"-no-such-flag"}, want: []string{"no-such-flag"}},
+		{name: "unknown setting in file", file: `{"no-such-setting": 1}`, want: []string{`unknown setting "no-such-setting"`}},
+		{name: "config named in file", file: `{"config": "other.json"}`, want: []string{`unknown setting "config"`}},
+		{name: "file not an object", file: `[1, 2]`, want: []string{"routeguide.json"}},
+		{
+			name: "bad values in file",
+			file: `{"notes-ttl": "soon", "max-streams": "many"}`,
+			want: []string{"max-streams", "notes-ttl"},
+		},
+		{
+			name: "bad environment value",
+			env:  map[string]string{"ROUTEGUIDE_RATE_BURST": "lots"},
+			want: []string{"ROUTEGUIDE_RATE_BURST"},
+		},
+		{name: "missing config file", args: []string{"-config", "/nonexistent/routeguide.json"}, want: []string{"/nonexistent/routeguide.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			_, err := testLoadConfig(t, tt.file, tt.args...)
+			if err == nil {
+				t.Fatal("loadConfig succeeded")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string // the start of each line of the error, in order
+	}{
+		{name: "defaults"},
+		{name: "unix socket", args: []string{"-listen", "unix:/tmp/routeguide.sock"}},
+		{name: "empty unix socket", args: []string{"-listen", "unix:"}, want: []string{"listen:"}},
+		{name: "listen without port", args: []string{"-listen", "localhost"}, want: []string{"listen:"}},
+		{name: "listen port out of range", args: []string{"-listen", ":65536"}, want: []string{"listen:"}},
+		{name: "tls cert without key", args: []string{"-tls-cert", "server.pem"}, want: []string{"tls-cert and tls-key:", "tls-cert:"}},
+		{name: "issuer without keys", args: []string{"-jwt-issuer", "example"}, want: []string{"jwt-issuer and jwt-audience:"}},
+		{name: "rate burst unused", args: []string{"-rate-burst", "0"}},
+		{name: "rate burst with rate", args: []string{"-rate-limit", "1", "-rate-burst", "0"}, want: []string{"rate-burst:"}},
+		{name: "fsync policy", args: []string{"-notes-fsync", "sometimes"}, want: []string{"notes-fsync:"}},
+		{
+			name: "every bad setting",
+			args: []string{
+				"-max-recv-msg-size", "0",
+				"-keepalive-timeout", "0s",
+				"-rate-limit", "-1",
+				"-max-streams", "-1",
+				"-log-format", "xml",
+				"-notes-ttl", "-1h",
+				"-janitor-interval", "0s",
+			},
+			want: []string{
+				"max-recv-msg-size:",
+				"keepalive-timeout:",
+				"rate-limit:",
+				"max-streams:",
+				"log-format:",
+				"notes-ttl:",
+				"janitor-interval:",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testLoadConfig(t, "", tt.args...)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("loadConfig: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("loadConfig succeeded, want %d errors", len(tt.want))
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d:\n%v", len(lines), len(tt.want), err)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("error %d is %q, want it to start %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestMainRejectsInvalidConfig runs the server in a child process, as main
+// exits the process
+func TestMainRejectsInvalidConfig(t *testing.T) {
+	if os.Getenv("RUN_ROUTEGUIDE_MAIN") == "1" {
+		os.Args = []string{"routeguide", "-log-format", "xml", "-rate-limit", "-1"}
+		main()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMainRejectsInvalidConfig$")
+	cmd.Env = append(os.Environ(), "RUN_ROUTEGUIDE_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+		t.Fatalf("server exited with %v, want status 2; output:\n%s", err, out)
+	}
+	for _, want := range []string{"invalid configuration", "rate-limit:", "log-format:"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output does not mention %q:\n%s", want, out)
+		}
+	}
+}
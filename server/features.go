@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	pb "routeguide/routeguide"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// E7 coordinate bounds; coordinates are degrees multiplied by 10^7
+const (
+	maxLatitudeE7  = 900000000
+	maxLongitudeE7 = 1800000000
+	e7             = 1e7
+)
+
+// dbFeature is one entry of a route_guide_db.json style array,
+// e.g. {"location": {"latitude": 407838351, "longitude": -746143763}, "name": "..."}
+type dbFeature struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Location *struct {
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	} `json:"location"`
+	featureMetadata
+}
+
+// featureMetadata holds the optional descriptive fields both formats share:
+// top-level in the array format, under properties in GeoJSON
+type featureMetadata struct {
+	Category    string            `json:"category"` // e.g. "park" or "CATEGORY_PARK"
+	Tags        map[string]string `json:"tags"`
+	Description string            `json:"description"`
+	Elevation   *float64          `json:"elevation_m"`
+	CreatedAt   *time.Time        `json:"created_at"` // RFC 3339
+	UpdatedAt   *time.Time        `json:"updated_at"`
+}
+
+// geoJSONCollection is a GeoJSON FeatureCollection whose features are Points
+// with coordinates in [longitude, latitude] degree order
+type geoJSONCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Type     string          `json:"type"`
+		ID       json.RawMessage `json:"id"` // GeoJSON allows a string or a number
+		Geometry *struct {
+			Type        string    `json:"type"`
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			Name string `json:"name"`
+			featureMetadata
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+// featureSet is an immutable snapshot of the feature catalogue. It is never
+// modified after construction, so any number of handlers can read it without
+// locking while a reload builds its replacement.
+type featureSet struct {
+	features  []*pb.Feature
+	byPoint   map[string]*pb.Feature // features keyed by serialize(location) for exact-point lookups
+	byID      map[string]*pb.Feature
+	index     spatialIndex
+	text      *textIndex    // SearchFeatures index over names and descriptions
+	idOrder   []*pb.Feature // features sorted by id, the default ListFeatures order
+	nameOrder []*pb.Feature // features sorted by name, then id
+}
+
+func newFeatureSet(features []*pb.Feature) *featureSet {
+	byPoint := make(map[string]*pb.Feature, len(features))
+	byID := make(map[string]*pb.Feature, len(features))
+	for _, feature := range features {
+		byPoint[serialize(feature.Location)] = feature
+		byID[feature.Id] = feature
+	}
+	return &featureSet{
+		features:  features,
+		byPoint:   byPoint,
+		byID:      byID,
+		index:     newSpatialIndex(features),
+		text:      newTextIndex(features),
+		idOrder:   sortedBy(features, idKey),
+		nameOrder: sortedBy(features, nameKey),
+	}
+}
+
+// findFeatureAtPoint checks if a point exists in the feature set
+// Returns the feature if found, nil otherwise
+func (fs *featureSet) findFeatureAtPoint(point *pb.Point) *pb.Feature {
+	return fs.byPoint[serialize(point)]
+}
+
+// loadFeatures reads a feature catalogue from path. The file may be either a
+// route_guide_db.json style array or a GeoJSON FeatureCollection of Points.
+func loadFeatures(path string) ([]*pb.Feature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	features, err := parseFeatures(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return features, nil
+}
+
+// parseFeatures detects the catalogue format from the first JSON token
+// and decodes it into features, rejecting bad coordinates and duplicate locations
+func parseFeatures(data []byte) ([]*pb.Feature, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty feature file")
+	}
+
+	var features []*pb.Feature
+	var err error
+	switch data[0] {
+	case '[':
+		features, err = parseDBFeatures(data)
+	case '{':
+		features, err = parseGeoJSONFeatures(data)
+	default:
+		return nil, fmt.Errorf("unrecognised feature file: expected a JSON array or a GeoJSON FeatureCollection")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	assignIDs(features)
+	if err := checkDuplicates(features); err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+func parseDBFeatures(data []byte) ([]*pb.Feature, error) {
+	var entries []dbFeature
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("decoding feature array: %w", err)
+	}
+
+	features := make([]*pb.Feature, 0, len(entries))
+	for i, entry := range entries {
+		if entry.Location == nil || entry.Location.Latitude == nil || entry.Location.Longitude == nil {
+			return nil, fmt.Errorf("features[%d]: missing location latitude/longitude", i)
+		}
+		if !isInteger(*entry.Location.Latitude) || !isInteger(*entry.Location.Longitude) {
+			return nil, fmt.Errorf("features[%d]: E7 coordinates must be integers, got (%v, %v)",
+				i, *entry.Location.Latitude, *entry.Location.Longitude)
+		}
+		lat, err := toE7(*entry.Location.Latitude, maxLatitudeE7, "latitude")
+		if err != nil {
+			return nil, fmt.Errorf("features[%d]: %w", i, err)
+		}
+		lon, err := toE7(*entry.Location.Longitude, maxLongitudeE7, "longitude")
+		if err != nil {
+			return nil, fmt.Errorf("features[%d]: %w", i, err)
+		}
+		feature := &pb.Feature{
+			Id:       entry.ID,
+			Name:     entry.Name,
+			Location: &pb.Point{Latitude: lat, Longitude: lon},
+		}
+		if err := entry.apply(feature); err != nil {
+			return nil, fmt.Errorf("features[%d]: %w", i, err)
+		}
+		features = append(features, feature)
+	}
+	return features, nil
+}
+
+func parseGeoJSONFeatures(data []byte) ([]*pb.Feature, error) {
+	var collection geoJSONCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("decoding GeoJSON: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("GeoJSON type is %q, want \"FeatureCollection\"", collection.Type)
+	}
+
+	features := make([]*pb.Feature, 0, len(collection.Features))
+	for i, f := range collection.Features {
+		if f.Geometry == nil || f.Geometry.Type != "Point" {
+			return nil, fmt.Errorf("features[%d]: geometry must be a GeoJSON Point", i)
+		}
+		if len(f.Geometry.Coordinates) < 2 {
+			return nil, fmt.Errorf("features[%d]: Point needs [longitude, latitude] coordinates, got %v", i, f.Geometry.Coordinates)
+		}
+		lat, err := toE7(f.Geometry.Coordinates[1]*e7, maxLatitudeE7, "latitude")
+		if err != nil {
+			return nil, fmt.Errorf("features[%d]: %w", i, err)
+		}
+		lon, err := toE7(f.Geometry.Coordinates[0]*e7, maxLongitudeE7, "longitude")
+		if err != nil {
+			return nil, fmt.Errorf("features[%d]: %w", i, err)
+		}
+		feature := &pb.Feature{
+			Id:       geoJSONID(f.ID),
+			Name:     f.Properties.Name,
+			Location: &pb.Point{Latitude: lat, Longitude: lon},
+		}
+		// A third coordinate is the altitude; an elevation_m property overrides it
+		if len(f.Geometry.Coordinates) > 2 {
+			feature.ElevationM = f.Geometry.Coordinates[2]
+		}
+		if err := f.Properties.apply(feature); err != nil {
+			return nil, fmt.Errorf("features[%d]: %w", i, err)
+		}
+		features = append(features, feature)
+	}
+	return features, nil
+}
+
+// apply copies the metadata that was present in the file onto feature
+func (m *featureMetadata) apply(feature *pb.Feature) error {
+	category, err := parseCategory(m.Category)
+	if err != nil {
+		return err
+	}
+	feature.Category = category
+	for key := range m.Tags {
+		if key == "" {
+			return fmt.Errorf("tag keys must not be empty")
+		}
+	}
+	feature.Tags = m.Tags
+	feature.Description = m.Description
+	if m.Elevation != nil {
+		feature.ElevationM = *m.Elevation
+	}
+	if m.CreatedAt != nil {
+		feature.CreatedAt = timestamppb.New(*m.CreatedAt)
+	}
+	if m.UpdatedAt != nil {
+		feature.UpdatedAt = timestamppb.New(*m.UpdatedAt)
+	}
+	return nil
+}
+
+// parseCategory accepts a category by its enum name, with or without the
+// CATEGORY_ prefix and in any case; "" means unspecified
+func parseCategory(s string) (pb.Category, error) {
+	if s == "" {
+		return pb.Category_CATEGORY_UNSPECIFIED, nil
+	}
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "CATEGORY_") {
+		name = "CATEGORY_" + name
+	}
+	if v, ok := pb.Category_value[name]; ok {
+		return pb.Category(v), nil
+	}
+	return 0, fmt.Errorf("unknown category %q", s)
+}
+
+// toE7 rounds an E7 value to int32 after checking it lies within ±limit
+func toE7(v float64, limit int32, field string) (int32, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%s is not a finite number", field)
+	}
+	r := math.Round(v)
+	if r < -float64(limit) || r > float64(limit) {
+		return 0, fmt.Errorf("%s %.0f out of range [%d, %d]", field, r, -limit, limit)
+	}
+	return int32(r), nil
+}
+
+func isInteger(v float64) bool {
+	return v == math.Trunc(v)
+}
+
+// geoJSONID returns a GeoJSON feature id as text, whether it was
+// given as a string or a number
+func geoJSONID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	return string(raw)
+}
+
+// checkDuplicates rejects catalogues where two features share a point,
+// since GetFeature could only ever return one of them, or share an id
+func checkDuplicates(features []*pb.Feature) error {
+	seen := make(map[string]int, len(features))
+	ids := make(map[string]int, len(features))
+	for i, feature := range features {
+		key := serialize(feature.Location)
+		if j, ok := seen[key]; ok {
+			return fmt.Errorf("features[%d] (%q): duplicate location (%s), already used by features[%d] (%q)",
+				i, feature.Name, key, j, features[j].Name)
+		}
+		seen[key] = i
+		if j, ok := ids[feature.Id]; ok {
+			return fmt.Errorf("features[%d] (%q): duplicate id %q, already used by features[%d] (%q)",
+				i, feature.Name, feature.Id, j, features[j].Name)
+		}
+		ids[feature.Id] = i
+	}
+	return nil
+}
+
+// assignIDs gives every feature without an id one derived from its location,
+// so the same file yields the same ids on every load
+func assignIDs(features []*pb.Feature) {
+	for _, feature := range features {
+		if feature.Id == "" {
+			feature.Id = locationID(feature.Location)
+		}
+	}
+}
+
+func locationID(p *pb.Point) string {
+	h := fnv.New64a()
+	h.Write([]byte(serialize(p)))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// demoFeatures returns the built-in sample catalogue of US landmarks,
+// used when no feature file is given
+func demoFeatures() []*pb.Feature {
+	features := []*pb.Feature{
+		{
+			Name:        "Liberty Bell",
+			Location:    &pb.Point{Latitude: 395906000, Longitude: -753506000},
+			Category:    pb.Category_CATEGORY_LANDMARK,
+			Description: "Symbol of American independence, on display in Philadelphia",
+			ElevationM:  16,
+		},
+		{
+			Name:        "Statue of Liberty",
+			Location:    &pb.Point{Latitude: 405847500, Longitude: -741301800},
+			Category:    pb.Category_CATEGORY_MONUMENT,
+			Description: "Copper statue on Liberty Island in New York Harbor",
+			ElevationM:  3,
+		},
+		{
+			Name:        "Empire State Building",
+			Location:    &pb.Point{Latitude: 407486500, Longitude: -739885900},
+			Category:    pb.Category_CATEGORY_BUILDING,
+			Description: "102-storey Art Deco skyscraper in Midtown Manhattan",
+			ElevationM:  17,
+		},
+		{
+			Name:        "Golden Gate Bridge",
+			Location:    &pb.Point{Latitude: 378197400, Longitude: -1224650700},
+			Category:    pb.Category_CATEGORY_BRIDGE,
+			Description: "Suspension bridge across the Golden Gate strait",
+			ElevationM:  67,
+		},
+		{
+			Name:        "Lincoln Memorial",
+			Location:    &pb.Point{Latitude: 389030600, Longitude: -770494800},
+			Category:    pb.Category_CATEGORY_MONUMENT,
+			Description: "Memorial to Abraham Lincoln at the west end of the National Mall",
+			ElevationM:  10,
+		},
+		{
+			Name:        "Mount Rushmore",
+			Location:    &pb.Point{Latitude: 438813500, Longitude: -1031032800},
+			Category:    pb.Category_CATEGORY_MONUMENT,
+			Description: "Presidents' faces carved into the Black Hills granite",
+			ElevationM:  1745,
+		},
+		{
+			Name:        "Space Needle",
+			Location:    &pb.Point{Latitude: 476203100, Longitude: -1221315600},
+			Category:    pb.Category_CATEGORY_LANDMARK,
+			Description: "Observation tower built for the 1962 World's Fair",
+			ElevationM:  158,
+		},
+	}
+	assignIDs(features)
+	return features
+}
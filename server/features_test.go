@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	pb "routeguide/routeguide"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseFeatures(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *pb.Feature // the first feature parsed
+	}{
+		{
+			name: "E7 array",
+			data: `[{"id": "a", "name": "A", "location": {"latitude": 407838351, "longitude": -746143763}, "category": "park"}]`,
+			want: &pb.Feature{Id: "a", Name: "A", Location: pt(407838351, -746143763), Category: pb.Category_CATEGORY_PARK},
+		},
+		{
+			name: "E7 array at the limits",
+			data: `[{"id": "a", "location": {"latitude": -900000000, "longitude": 1800000000}, "category": "CATEGORY_viewpoint"}]`,
+			want: &pb.Feature{Id: "a", Location: pt(-900000000, 1800000000), Category: pb.Category_CATEGORY_VIEWPOINT},
+		},
+		{
+			name: "GeoJSON",
+			data: `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": 7,
+				"geometry": {"type": "Point", "coordinates": [-74.6143763, 40.7838351]}, "properties": {"name": "A"}}]}`,
+			want: &pb.Feature{Id: "7", Name: "A", Location: pt(407838351, -746143763)},
+		},
+		{
+			name: "GeoJSON elevation from the third coordinate",
+			data: `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": "a",
+				"geometry": {"type": "Point", "coordinates": [-74.6, 40.7, 123.5]}, "properties": {}}]}`,
+			want: &pb.Feature{Id: "a", Location: pt(407000000, -746000000), ElevationM: 123.5},
+		},
+		{
+			name: "GeoJSON elevation_m over the third coordinate",
+			data: `{"type": "FeatureCollection", "features": [{"type": "Feature", "id": "a",
+				"geometry": {"type": "Point", "coordinates": [-74.6, 40.7, 123.5]}, "properties": {"elevation_m": 99}}]}`,
+			want: &pb.Feature{Id: "a", Location: pt(407000000, -746000000), ElevationM: 99},
+		},
+		{
+			name: "id derived from the location",
+			data: `[{"location": {"latitude": 1, "longitude": 2}}]`,
+			want: &pb.Feature{Id: locationID(pt(1, 2)), Location: pt(1, 2)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features, err := parseFeatures([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseFeatures: %v", err)
+			}
+			if !proto.Equal(features[0], tt.want) {
+				t.Errorf("parsed %v, want %v", features[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeaturesRejects(t *testing.T) {
+	geoJSON := func(coordinates string) string {
+		return `{"type": "FeatureCollection", "features": [{"type": "Feature",
+			"geometry": {"type": "Point", "coordinates": ` + coordinates + `}, "properties": {}}]}`
+	}
+	tests := []struct {
+		name string
+		data string
+		want string // a substring of the error
+	}{
+		{name: "empty", data: " ", want: "empty feature file"},
+		{name: "not JSON", data: "latitude,longitude", want: "unrecognised feature file"},
+		{name: "missing location", data: `[{"name": "A"}]`, want: "features[0]: missing location"},
+		{name: "E7 not integers", data: `[{"location": {"latitude": 40.78, "longitude": -74.61}}]`, want: "E7 coordinates must be integers"},
+		{name: "E7 latitude above range", data: `[{"location": {"latitude": 900000001, "longitude": 0}}]`, want: "latitude 900000001 out of range"},
+		{name: "E7 latitude below range", data: `[{"location": {"latitude": -900000001, "longitude": 0}}]`, want: "latitude -900000001 out of range"},
+		{name: "E7 longitude above range", data: `[{"location": {"latitude": 0, "longitude": 1800000001}}]`, want: "longitude 1800000001 out of range"},
+		{name: "E7 longitude beyond int32", data: `[{"location": {"latitude": 0, "longitude": -3000000000}}]`, want: "longitude -3000000000 out of range"},
+		{name: "GeoJSON latitude out of range", data: geoJSON("[0, 90.5]"), want: "features[0]: latitude 905000000 out of range"},
+		{name: "GeoJSON longitude out of range", data: geoJSON("[-180.5, 0]"), want: "features[0]: longitude -1805000000 out of range"},
+		{name: "GeoJSON one coordinate", data: geoJSON("[1]"), want: "needs [longitude, latitude]"},
+		{
+			name: "GeoJSON not a point",
+			data: `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "LineString", "coordinates": []}}]}`,
+			want: "geometry must be a GeoJSON Point",
+		},
+		{name: "GeoJSON not a collection", data: `{"type": "Feature"}`, want: `GeoJSON type is "Feature"`},
+		{
+			name: "duplicate location",
+			data: `[{"name": "A", "location": {"latitude": 1, "longitude": 2}}, {"name": "B", "location": {"latitude": 1, "longitude": 2}}]`,
+			want: `features[1] ("B"): duplicate location`,
+		},
+		{
+			name: "GeoJSON duplicate location after rounding to E7",
+			data: `{"type": "FeatureCollection", "features": [
+				{"type": "Feature", "id": "a", "geometry": {"type": "Point", "coordinates": [2, 1]}, "properties": {}},
+				{"type": "Feature", "id": "b", "geometry": {"type": "Point", "coordinates": [2.00000000001, 1]}, "properties": {}}]}`,
+			want: "features[1] (\"\"): duplicate location",
+		},
+		{
+			name: "duplicate id",
+			data: `[{"id": "a", "location": {"latitude": 1, "longitude": 2}}, {"id": "a", "location": {"latitude": 3, "longitude": 4}}]`,
+			want: `duplicate id "a"`,
+		},
+		{name: "unknown category", data: `[{"location": {"latitude": 1, "longitude": 2}, "category": "volcano"}]`, want: `features[0]: unknown category "volcano"`},
+		{
+			name: "GeoJSON unknown category",
+			data: `{"type": "FeatureCollection", "features": [{"type": "Feature",
+				"geometry": {"type": "Point", "coordinates": [2, 1]}, "properties": {"category": "CATEGORY_"}}]}`,
+			want: `unknown category "CATEGORY_"`,
+		},
+		{name: "empty tag key", data: `[{"location": {"latitude": 1, "longitude": 2}, "tags": {"": "x"}}]`, want: "tag keys must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFeatures([]byte(tt.data))
+			if err == nil {
+				t.Fatal("parseFeatures succeeded")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+
+	// loadFeatures names the file in the error
+	path := filepath.Join(t.TempDir(), "features.json")
+	writeFile(t, path, []byte(`[{"location": {"latitude": 1, "longitude": 2}, "category": "volcano"}]`))
+	if _, err := loadFeatures(path); err == nil || !strings.HasPrefix(err.Error(), path+": ") {
+		t.Errorf("loadFeatures error %v does not start with the path", err)
+	}
+}
+
+// BenchmarkFindFeatureAtPoint compares the byPoint map lookup with the
+// proto.Equal scan over every feature that it replaced. Each lookup hits a
+// feature halfway through the catalogue, the scan's average case.
+func BenchmarkFindFeatureAtPoint(b *testing.B) {
+	for _, n := range []int{100, 10_000, 1_000_000} {
+		features := randomFeatures(n)
+		fs := newFeatureSet(features)
+		point := &pb.Point{Latitude: features[n/2].Location.Latitude, Longitude: features[n/2].Location.Longitude}
+
+		b.Run(fmt.Sprintf("map/n=%d", n), func(b *testing.B) {
+			for b.Loop() {
+				if fs.findFeatureAtPoint(point) == nil {
+					b.Fatal("feature not found")
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("scan/n=%d", n), func(b *testing.B) {
+			for b.Loop() {
+				var found *pb.Feature
+				for _, feature := range fs.features {
+					if proto.Equal(feature.Location, point) {
+						found = feature
+						break
+					}
+				}
+				if found == nil {
+					b.Fatal("feature not found")
+				}
+			}
+		})
+	}
+}
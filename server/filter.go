@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	pb "routeguide/routeguide"
+	"strings"
+)
+
+// featureFilter is a validated pb.FeatureFilter with its name patterns
+// case-folded once per request
+type featureFilter struct {
+	namePrefix   string
+	nameContains string
+	categories   map[pb.Category]bool // nil allows any category
+	tags         []*pb.TagPredicate
+}
+
+// newFeatureFilter validates f, which may be nil, and records violations
+// under field
+func newFeatureFilter(br *badRequest, field string, f *pb.FeatureFilter) *featureFilter {
+	if f == nil {
+		return nil
+	}
+	filter := &featureFilter{
+		namePrefix:   strings.ToLower(f.NamePrefix),
+		nameContains: strings.ToLower(f.NameContains),
+		tags:         f.Tags,
+	}
+	for i, category := range f.Categories {
+		if _, ok := pb.Category_name[int32(category)]; !ok {
+			br.add(fmt.Sprintf("%s.categories[%d]", field, i), "unknown category %d", category)
+			continue
+		}
+		if filter.categories == nil {
+			filter.categories = make(map[pb.Category]bool, len(f.Categories))
+		}
+		filter.categories[category] = true
+	}
+	for i, tag := range f.Tags {
+		if tag.Key == "" {
+			br.add(fmt.Sprintf("%s.tags[%d].key", field, i), "is required")
+		}
+		if _, ok := pb.TagPredicate_Op_name[int32(tag.Op)]; !ok {
+			br.add(fmt.Sprintf("%s.tags[%d].op", field, i), "unknown operator %d", tag.Op)
+		}
+	}
+	return filter
+}
+
+// matches reports whether feature passes the filter. A nil filter passes everything.
+func (filter *featureFilter) matches(feature *pb.Feature) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.categories != nil && !filter.categories[feature.Category] {
+		return false
+	}
+	if filter.namePrefix != "" || filter.nameContains != "" {
+		name := strings.ToLower(feature.Name)
+		if !strings.HasPrefix(name, filter.namePrefix) || !strings.Contains(name, filter.nameContains) {
+			return false
+		}
+	}
+	for _, tag := range filter.tags {
+		value, ok := feature.Tags[tag.Key]
+		switch tag.Op {
+		case pb.TagPredicate_OP_EXISTS:
+			if !ok {
+				return false
+			}
+		case pb.TagPredicate_OP_EQUALS:
+			if !ok || value != tag.Value {
+				return false
+			}
+		case pb.TagPredicate_OP_NOT_EQUALS:
+			if ok && value == tag.Value {
+				return false
+			}
+		case pb.TagPredicate_OP_ABSENT:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	pb "routeguide/routeguide"
+)
+
+// earthRadius is the mean Earth radius in metres
+const earthRadius = 6371000
+
+func toRadians(e7Degrees int32) float64 {
+	return float64(e7Degrees) / e7 * math.Pi / 180
+}
+
+// distance returns the great-circle distance between two points in metres,
+// using the haversine formula
+func distance(p1, p2 *pb.Point) float64 {
+	return haversineDistance(toRadians(p1.Latitude), toRadians(p2.Latitude),
+		toRadians(p2.Longitude)-toRadians(p1.Longitude))
+}
+
+// haversineDistance is the great-circle distance in metres between two
+// latitudes (in radians) separated by dLon radians of longitude
+func haversineDistance(lat1, lat2, dLon float64) float64 {
+	dLat := lat2 - lat1
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(math.Max(0, 1-a)))
+}
+
+// boxDistance returns a lower bound on the great-circle distance in metres
+// from p to any point inside b, which is what nearest-neighbour search needs
+// to decide whether a subtree can still contain a closer feature
+func boxDistance(p *pb.Point, b bounds) float64 {
+	lat := toRadians(p.Latitude)
+	minLat, maxLat := toRadians(b.minLat), toRadians(b.maxLat)
+
+	// Directly north or south of the box: the nearest point is on the same meridian
+	if p.Longitude >= b.minLon && p.Longitude <= b.maxLon {
+		switch {
+		case lat < minLat:
+			return haversineDistance(lat, minLat, 0)
+		case lat > maxLat:
+			return haversineDistance(lat, maxLat, 0)
+		}
+		return 0
+	}
+
+	// East or west of the box: the nearest point lies on the closer edge meridian,
+	// either where the great circle through p meets it at a right angle or at a corner
+	dLon := math.Min(lonDelta(p.Longitude, b.minLon), lonDelta(p.Longitude, b.maxLon))
+	if math.Cos(dLon) > 0 {
+		vertex := math.Atan(math.Tan(lat) / math.Cos(dLon))
+		if vertex > minLat && vertex < maxLat {
+			return haversineDistance(lat, vertex, dLon)
+		}
+	}
+	return math.Min(haversineDistance(lat, minLat, dLon), haversineDistance(lat, maxLat, dLon))
+}
+
+// boxMaxDistance returns an upper bound on the great-circle distance in
+// metres from p to any point inside b: the distance to a corner plus the
+// length of a path from that corner along a meridian and then a parallel,
+// which is no longer than the box's span in both directions at the equator.
+// A metre of slack covers rounding, so a subtree is only skipped for lying
+// entirely before a resume distance when it surely does.
+func boxMaxDistance(p *pb.Point, b bounds) float64 {
+	corner := &pb.Point{Latitude: b.minLat, Longitude: b.minLon}
+	span := toRadians(b.maxLat) - toRadians(b.minLat) + toRadians(b.maxLon) - toRadians(b.minLon)
+	return distance(p, corner) + span*earthRadius + 1
+}
+
+// lonDelta is the absolute longitude difference in radians, going the
+// short way around the antimeridian
+func lonDelta(lon1, lon2 int32) float64 {
+	d := math.Abs(toRadians(lon1) - toRadians(lon2))
+	if d > math.Pi {
+		d = 2*math.Pi - d
+	}
+	return d
+}
+
+// circleBounds returns boxes covering every point within the circle. The
+// longitude span is that of the spherical cap; a circle that crosses the
+// antimeridian is split in two and one that reaches a pole covers all
+// longitudes. Boxes are padded by one E7 unit so rounding never drops a
+// feature lying exactly on the boundary.
+func circleBounds(c *pb.Circle) []bounds {
+	angle := c.RadiusM / earthRadius
+	lat := toRadians(c.Center.Latitude)
+	minLat, maxLat := lat-angle, lat+angle
+
+	b := bounds{
+		minLat: fromRadians(minLat, maxLatitudeE7, math.Floor) - 1,
+		maxLat: fromRadians(maxLat, maxLatitudeE7, math.Ceil) + 1,
+		minLon: -maxLongitudeE7,
+		maxLon: maxLongitudeE7,
+	}
+	b.minLat = max(b.minLat, -maxLatitudeE7)
+	b.maxLat = min(b.maxLat, maxLatitudeE7)
+	if minLat <= -math.Pi/2 || maxLat >= math.Pi/2 || angle >= math.Pi {
+		return []bounds{b}
+	}
+
+	// Work in int64 since the span can run past ±180° before wrapping
+	dLon := math.Asin(math.Min(1, math.Sin(angle)/math.Cos(lat)))
+	lon := toRadians(c.Center.Longitude)
+	west := int64(math.Floor((lon-dLon)*180/math.Pi*e7)) - 1
+	east := int64(math.Ceil((lon+dLon)*180/math.Pi*e7)) + 1
+	const fullTurn = 2 * maxLongitudeE7
+	switch {
+	case west < -maxLongitudeE7:
+		return []bounds{
+			{minLat: b.minLat, maxLat: b.maxLat, minLon: -maxLongitudeE7, maxLon: int32(east)},
+			{minLat: b.minLat, maxLat: b.maxLat, minLon: int32(west + fullTurn), maxLon: maxLongitudeE7},
+		}
+	case east > maxLongitudeE7:
+		return []bounds{
+			{minLat: b.minLat, maxLat: b.maxLat, minLon: int32(west), maxLon: maxLongitudeE7},
+			{minLat: b.minLat, maxLat: b.maxLat, minLon: -maxLongitudeE7, maxLon: int32(east - fullTurn)},
+		}
+	}
+	b.minLon, b.maxLon = int32(west), int32(east)
+	return []bounds{b}
+}
+
+// fromRadians converts radians to E7 degrees, rounding with round and
+// clamping to ±limit so the result fits in an int32
+func fromRadians(r float64, limit int32, round func(float64) float64) int32 {
+	v := round(r * 180 / math.Pi * e7)
+	return int32(math.Max(-float64(limit), math.Min(float64(limit), v)))
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"cmp"
+	"container/heap"
+	pb "routeguide/routeguide"
+	"slices"
+	"sort"
+)
+
+// rtreeNodeSize is the fan-out of the packed R-tree
+const rtreeNodeSize = 16
+
+// minIndexedFeatures is the catalogue size below which a linear scan
+// is as fast as walking a tree, so no index is built
+const minIndexedFeatures = 64
+
+// bounds is an inclusive bounding box in E7 coordinates
+type bounds struct {
+	minLat, minLon int32
+	maxLat, maxLon int32
+}
+
+func pointBounds(p *pb.Point) bounds {
+	return bounds{minLat: p.Latitude, minLon: p.Longitude, maxLat: p.Latitude, maxLon: p.Longitude}
+}
+
+func (b bounds) intersects(o bounds) bool {
+	return b.minLat <= o.maxLat && o.minLat <= b.maxLat &&
+		b.minLon <= o.maxLon && o.minLon <= b.maxLon
+}
+
+func (b bounds) contains(p *pb.Point) bool {
+	return p.Latitude >= b.minLat && p.Latitude <= b.maxLat &&
+		p.Longitude >= b.minLon && p.Longitude <= b.maxLon
+}
+
+func (b bounds) union(o bounds) bounds {
+	return bounds{
+		minLat: min(b.minLat, o.minLat), minLon: min(b.minLon, o.minLon),
+		maxLat: max(b.maxLat, o.maxLat), maxLon: max(b.maxLon, o.maxLon),
+	}
+}
+
+// anyContains reports whether p lies in at least one of boxes
+func anyContains(boxes []bounds, p *pb.Point) bool {
+	return slices.ContainsFunc(boxes, func(b bounds) bool { return b.contains(p) })
+}
+
+// inAny is anyContains for an optional set of boxes, where nil means
+// everywhere
+func inAny(boxes []bounds, p *pb.Point) bool {
+	return boxes == nil || anyContains(boxes, p)
+}
+
+// spatialIndex answers bounding-box queries over an immutable set of features
+type spatialIndex interface {
+	// search calls fn for every feature located inside b until fn returns false
+	search(b bounds, fn func(*pb.Feature) bool)
+	// nearest calls fn for every feature inside any of boxes (nil for
+	// anywhere) at distance from or more, in order of increasing great-circle
+	// distance from p, then id, until fn returns false
+	nearest(p *pb.Point, boxes []bounds, from float64, fn func(feature *pb.Feature, dist float64) bool)
+	// hilbert calls fn for every feature inside any of boxes whose Hilbert key
+	// is at least from, in order of key, then id, until fn returns false
+	hilbert(boxes []bounds, from uint64, fn func(feature *pb.Feature, key uint64) bool)
+}
+
+// newSpatialIndex picks an index implementation suited to the catalogue size
+func newSpatialIndex(features []*pb.Feature) spatialIndex {
+	if len(features) < minIndexedFeatures {
+		sorted, _ := hilbertSort(features)
+		return scanIndex(sorted)
+	}
+	return newRTree(features)
+}
+
+// hilbertSort returns the features ordered by Hilbert key, then id,
+// along with their keys
+func hilbertSort(features []*pb.Feature) ([]*pb.Feature, []uint64) {
+	type keyed struct {
+		key     uint64
+		feature *pb.Feature
+	}
+	entries := make([]keyed, len(features))
+	for i, feature := range features {
+		entries[i] = keyed{hilbertKey(feature.Location), feature}
+	}
+	slices.SortFunc(entries, func(a, b keyed) int {
+		if c := cmp.Compare(a.key, b.key); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.feature.Id, b.feature.Id)
+	})
+	sorted := make([]*pb.Feature, len(entries))
+	keys := make([]uint64, len(entries))
+	for i, entry := range entries {
+		sorted[i], keys[i] = entry.feature, entry.key
+	}
+	return sorted, keys
+}
+
+// scanIndex is the trivial index: it checks every feature. The features
+// are kept in Hilbert order so that hilbert needs no sorting.
+type scanIndex []*pb.Feature
+
+func (idx scanIndex) search(b bounds, fn func(*pb.Feature) bool) {
+	for _, feature := range idx {
+		if b.contains(feature.Location) && !fn(feature) {
+			return
+		}
+	}
+}
+
+func (idx scanIndex) nearest(p *pb.Point, boxes []bounds, from float64, fn func(*pb.Feature, float64) bool) {
+	var candidates []neighbour
+	for _, feature := range idx {
+		if !inAny(boxes, feature.Location) {
+			continue
+		}
+		if dist := distance(p, feature.Location); dist >= from {
+			candidates = append(candidates, neighbour{feature: feature, dist: dist})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].before(candidates[j]) })
+	for _, c := range candidates {
+		if !fn(c.feature, c.dist) {
+			return
+		}
+	}
+}
+
+func (idx scanIndex) hilbert(boxes []bounds, from uint64, fn func(*pb.Feature, uint64) bool) {
+	for _, feature := range idx {
+		key := hilbertKey(feature.Location)
+		if key >= from && anyContains(boxes, feature.Location) && !fn(feature, key) {
+			return
+		}
+	}
+}
+
+// rtree is a static R-tree bulk-loaded by sorting features along a Hilbert
+// curve and packing them into full nodes, which keeps nearby features in
+// the same leaves without any rebalancing. It is rebuilt on every reload.
+type rtree struct {
+	root *rtreeNode
+}
+
+type rtreeNode struct {
+	bounds   bounds
+	maxKey   uint64        // largest Hilbert key beneath the node
+	children []*rtreeNode  // inner nodes only
+	features []*pb.Feature // leaves only
+	keys     []uint64      // leaves only: the Hilbert key of each feature
+}
+
+func newRTree(features []*pb.Feature) *rtree {
+	sorted, keys := hilbertSort(features)
+
+	// Pack the sorted features into leaves
+	var level []*rtreeNode
+	for start := 0; start < len(sorted); start += rtreeNodeSize {
+		end := min(start+rtreeNodeSize, len(sorted))
+		leaf := &rtreeNode{
+			features: sorted[start:end:end],
+			keys:     keys[start:end:end],
+			maxKey:   keys[end-1],
+			bounds:   pointBounds(sorted[start].Location),
+		}
+		for _, feature := range leaf.features[1:] {
+			leaf.bounds = leaf.bounds.union(pointBounds(feature.Location))
+		}
+		level = append(level, leaf)
+	}
+	if len(level) == 0 {
+		return &rtree{}
+	}
+
+	// Group consecutive nodes into parents until a single root remains
+	for len(level) > 1 {
+		var parents []*rtreeNode
+		for start := 0; start < len(level); start += rtreeNodeSize {
+			end := min(start+rtreeNodeSize, len(level))
+			parent := &rtreeNode{children: level[start:end:end], bounds: level[start].bounds, maxKey: level[end-1].maxKey}
+			for _, child := range parent.children[1:] {
+				parent.bounds = parent.bounds.union(child.bounds)
+			}
+			parents = append(parents, parent)
+		}
+		level = parents
+	}
+	return &rtree{root: level[0]}
+}
+
+func (t *rtree) search(b bounds, fn func(*pb.Feature) bool) {
+	if t.root != nil {
+		t.root.search(b, fn)
+	}
+}
+
+// search returns false once fn has asked to stop
+func (n *rtreeNode) search(b bounds, fn func(*pb.Feature) bool) bool {
+	if !n.bounds.intersects(b) {
+		return true
+	}
+	for _, feature := range n.features {
+		if b.contains(feature.Location) && !fn(feature) {
+			return false
+		}
+	}
+	for _, child := range n.children {
+		if !child.search(b, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *rtree) hilbert(boxes []bounds, from uint64, fn func(*pb.Feature, uint64) bool) {
+	if t.root != nil {
+		t.root.hilbert(boxes, from, fn)
+	}
+}
+
+// hilbert visits the node's features in tree order, which is Hilbert order
+// because the tree was packed from Hilbert-sorted leaves. Subtrees that lie
+// outside every box or end before from are skipped. It returns false once
+// fn has asked to stop.
+func (n *rtreeNode) hilbert(boxes []bounds, from uint64, fn func(*pb.Feature, uint64) bool) bool {
+	if n.maxKey < from || !slices.ContainsFunc(boxes, n.bounds.intersects) {
+		return true
+	}
+	for i, feature := range n.features {
+		if n.keys[i] >= from && anyContains(boxes, feature.Location) && !fn(feature, n.keys[i]) {
+			return false
+		}
+	}
+	for _, child := range n.children {
+		if !child.hilbert(boxes, from, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// nearest does a best-first traversal: nodes and features share one queue
+// ordered by distance, so a feature is only reported once nothing left
+// in the queue could be closer. At equal distances nodes come first, so
+// every feature at that distance is queued before any is reported, in id order.
+// Subtrees outside boxes or entirely closer than from are never queued, so
+// resuming a search far from p does not revisit what lies before from.
+func (t *rtree) nearest(p *pb.Point, boxes []bounds, from float64, fn func(*pb.Feature, float64) bool) {
+	if t.root == nil {
+		return
+	}
+	wanted := func(n *rtreeNode) bool {
+		return (boxes == nil || slices.ContainsFunc(boxes, n.bounds.intersects)) &&
+			(from == 0 || boxMaxDistance(p, n.bounds) >= from)
+	}
+	if !wanted(t.root) {
+		return
+	}
+	queue := &neighbourQueue{{node: t.root, dist: boxDistance(p, t.root.bounds)}}
+	for queue.Len() > 0 {
+		next := heap.Pop(queue).(neighbour)
+		if next.feature != nil {
+			if !fn(next.feature, next.dist) {
+				return
+			}
+			continue
+		}
+		for _, feature := range next.node.features {
+			if !inAny(boxes, feature.Location) {
+				continue
+			}
+			if dist := distance(p, feature.Location); dist >= from {
+				heap.Push(queue, neighbour{feature: feature, dist: dist})
+			}
+		}
+		for _, child := range next.node.children {
+			if wanted(child) {
+				heap.Push(queue, neighbour{node: child, dist: boxDistance(p, child.bounds)})
+			}
+		}
+	}
+}
+
+// neighbour is a nearest-search queue entry: either a feature with its exact
+// distance or an R-tree node with a lower bound for everything beneath it
+type neighbour struct {
+	feature *pb.Feature
+	node    *rtreeNode
+	dist    float64
+}
+
+// before orders neighbours by distance; at equal distances nodes precede
+// features and features are ordered by id
+func (n neighbour) before(o neighbour) bool {
+	if n.dist != o.dist {
+		return n.dist < o.dist
+	}
+	if n.feature == nil || o.feature == nil {
+		return n.feature == nil && o.feature != nil
+	}
+	return n.feature.Id < o.feature.Id
+}
+
+// neighbourQueue is a min-heap of neighbours by distance
+type neighbourQueue []neighbour
+
+func (q neighbourQueue) Len() int           { return len(q) }
+func (q neighbourQueue) Less(i, j int) bool { return q[i].before(q[j]) }
+func (q neighbourQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *neighbourQueue) Push(x any)        { *q = append(*q, x.(neighbour)) }
+func (q *neighbourQueue) Pop() any {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// hilbertKey maps a point to its distance along a Hilbert curve covering
+// the whole E7 coordinate space at 16 bits of resolution per axis
+func hilbertKey(p *pb.Point) uint64 {
+	const order = 1 << 16
+	x := uint32(int64(p.Longitude)+maxLongitudeE7) >> 16
+	y := uint32(int64(p.Latitude)+maxLatitudeE7) >> 15 // latitude spans half the range
+	var d uint64
+	for s := uint32(order / 2); s > 0; s /= 2 {
+		var rx, ry uint32
+		if x&s != 0 {
+			rx = 1
+		}
+		if y&s != 0 {
+			ry = 1
+		}
+		d += uint64(s) * uint64(s) * uint64((3*rx)^ry)
+		// Rotate the quadrant so the curve stays continuous
+		if ry == 0 {
+			if rx == 1 {
+				x = order - 1 - x
+				y = order - 1 - y
+			}
+			x, y = y, x
+		}
+	}
+	return d
+}
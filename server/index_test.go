@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	pb "routeguide/routeguide"
+	"testing"
+)
+
+// randomFeatures returns n features scattered uniformly over the globe,
+// the same ones for the same n
+func randomFeatures(n int) []*pb.Feature {
+	r := rand.New(rand.NewPCG(1, uint64(n)))
+	features := make([]*pb.Feature, n)
+	for i := range features {
+		features[i] = &pb.Feature{
+			Id:   fmt.Sprintf("f%07d", i),
+			Name: fmt.Sprintf("Feature %d", i),
+			Location: &pb.Point{
+				Latitude:  int32(r.Int64N(2*maxLatitudeE7+1) - maxLatitudeE7),
+				Longitude: int32(r.Int64N(2*maxLongitudeE7+1) - maxLongitudeE7),
+			},
+		}
+	}
+	return features
+}
+
+// BenchmarkSearch compares a one-degree-square bounding-box query against
+// the linear scan and the R-tree as the catalogue grows
+func BenchmarkSearch(b *testing.B) {
+	query := bounds{minLat: 40e7, minLon: -75e7, maxLat: 41e7, maxLon: -74e7}
+	for _, n := range []int{1_000, 100_000, 1_000_000} {
+		features := randomFeatures(n)
+		sorted, _ := hilbertSort(features)
+		indexes := []struct {
+			name  string
+			index spatialIndex
+		}{
+			{"scan", scanIndex(sorted)},
+			{"rtree", newRTree(features)},
+		}
+		for _, idx := range indexes {
+			b.Run(fmt.Sprintf("%s/n=%d", idx.name, n), func(b *testing.B) {
+				for b.Loop() {
+					found := 0
+					idx.index.search(query, func(*pb.Feature) bool {
+						found++
+						return true
+					})
+				}
+			})
+		}
+	}
+}
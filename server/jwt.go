@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// jwtLeeway absorbs clock skew between the token issuer and this server
+	jwtLeeway = 30 * time.Second
+
+	// minHMACSecretLength is the shortest HS256 secret accepted, the size of
+	// the SHA-256 output, so secrets cannot be guessed more easily than forged
+	minHMACSecretLength = 32
+)
+
+// jwtVerifier checks compact-serialized JWTs signed with HS256 using a
+// shared secret or RS256 using a key from a local JWKS file. The algorithm
+// must match the kind of key configured for it, so an RS256 public key can
+// never be used as an HMAC secret.
+type jwtVerifier struct {
+	hmacSecret []byte                    // HS256; empty disables it
+	rsaKeys    map[string]*rsa.PublicKey // RS256 keys by "kid"; empty disables it
+	issuer     string                    // required "iss" if set
+	audience   string                    // required "aud" entry if set
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are the claims the server looks at
+type jwtClaims struct {
+	Subject   string     `json:"sub"`
+	Issuer    string     `json:"iss"`
+	Audience  jwtStrings `json:"aud"`
+	ExpiresAt *float64   `json:"exp"`
+	NotBefore *float64   `json:"nbf"`
+	Roles     jwtStrings `json:"roles"` // private claim naming the caller's policy roles
+}
+
+// jwtStrings is a claim such as "aud" that may be a string or an array of
+// strings
+type jwtStrings []string
+
+func (a *jwtStrings) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtStrings{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// verify checks token's signature and time and audience claims and returns
+// its claims
+func (v *jwtVerifier) verify(token string, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	digest := sha256.Sum256(signed)
+
+	switch header.Alg {
+	case "HS256":
+		if len(v.hmacSecret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, v.hmacSecret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, errors.New("bad signature")
+		}
+	case "RS256":
+		key, err := v.rsaKey(header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, errors.New("bad signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %w", err)
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("missing sub claim")
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("missing exp claim")
+	}
+	if now.After(unixTime(*claims.ExpiresAt).Add(jwtLeeway)) {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(unixTime(*claims.NotBefore)) {
+		return nil, errors.New("token not valid yet")
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return nil, errors.New("token is not for this audience")
+	}
+	return &claims, nil
+}
+
+// rsaKey finds the JWKS key for kid. A token without a kid is accepted only
+// when the JWKS holds a single key.
+func (v *jwtVerifier) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if len(v.rsaKeys) == 0 {
+		return nil, errors.New("RS256 tokens are not accepted")
+	}
+	if kid == "" && len(v.rsaKeys) == 1 {
+		for _, key := range v.rsaKeys {
+			return key, nil
+		}
+	}
+	key, ok := v.rsaKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unixTime converts a JWT NumericDate, which may have a fractional part
+func unixTime(seconds float64) time.Time {
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9))
+}
+
+// loadHMACSecret reads the HS256 secret from path. Surrounding whitespace,
+// such as a trailing newline, is not part of the secret.
+func loadHMACSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	secret := []byte(strings.TrimSpace(string(data)))
+	if len(secret) < minHMACSecretLength {
+		return nil, fmt.Errorf("%s: secret is %d bytes, must be at least %d", path, len(secret), minHMACSecretLength)
+	}
+	return secret, nil
+}
+
+// loadJWKS reads the RSA signing keys from a JWKS file. Keys of other types
+// or meant for encryption are skipped.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for i, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != "RS256") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("%s: keys[%d]: malformed RSA modulus or exponent", path, i)
+		}
+		if _, dup := keys[jwk.Kid]; dup {
+			return nil, fmt.Errorf("%s: keys[%d]: duplicate kid %q", path, i, jwk.Kid)
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no RS256 signing keys", path)
+	}
+	return keys, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testNow is the time tokens are verified at
+var testNow = time.Unix(1_700_000_000, 0)
+
+var testHMACSecret = []byte(strings.Repeat("s", minHMACSecretLength))
+
+// encodeJWT returns the signing input of a token with the given header and claims
+func encodeJWT(t *testing.T, header, claims map[string]any) string {
+	t.Helper()
+	part := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	return part(header) + "." + part(claims)
+}
+
+func signHS256(t *testing.T, secret []byte, header, claims map[string]any) string {
+	t.Helper()
+	signed := encodeJWT(t, header, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, header, claims map[string]any) string {
+	t.Helper()
+	signed := encodeJWT(t, header, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns claims that pass verification at testNow, with
+// changes applied; a nil value removes the claim
+func validClaims(changes map[string]any) map[string]any {
+	claims := map[string]any{
+		"sub": "alice",
+		"iss": "https://issuer.example",
+		"aud": []string{"routeguide", "other"},
+		"exp": testNow.Add(time.Hour).Unix(),
+		"nbf": testNow.Add(-time.Hour).Unix(),
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+func TestJWTVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs256 := map[string]any{"alg": "HS256", "typ": "JWT"}
+	rs256 := map[string]any{"alg": "RS256", "kid": "k1"}
+
+	both := &jwtVerifier{
+		hmacSecret: testHMACSecret,
+		rsaKeys:    map[string]*rsa.PublicKey{"k1": &rsaKey.PublicKey},
+		issuer:     "https://issuer.example",
+		audience:   "routeguide",
+	}
+	rsaOnly := &jwtVerifier{rsaKeys: map[string]*rsa.PublicKey{"k1": &rsaKey.PublicKey}}
+	twoKeys := &jwtVerifier{rsaKeys: map[string]*rsa.PublicKey{"k1": &rsaKey.PublicKey, "k2": &otherKey.PublicKey}}
+	oneKey := &jwtVerifier{rsaKeys: map[string]*rsa.PublicKey{"k1": &rsaKey.PublicKey}}
+
+	tests := []struct {
+		name     string
+		verifier *jwtVerifier
+		token    string
+		wantErr  string // empty if the token is valid
+	}{
+		{"HS256", both, signHS256(t, testHMACSecret, hs256, validClaims(nil)), ""},
+		{"RS256", both, signRS256(t, rsaKey, rs256, validClaims(nil)), ""},
+		{"RS256 chosen by kid", twoKeys, signRS256(t, otherKey, map[string]any{"alg": "RS256", "kid": "k2"}, validClaims(nil)), ""},
+		{"RS256 without kid and one key", oneKey, signRS256(t, rsaKey, map[string]any{"alg": "RS256"}, validClaims(nil)), ""},
+
+		{"HS256 bad signature", both, signHS256(t, []byte(strings.Repeat("x", 32)), hs256, validClaims(nil)), "bad signature"},
+		{"RS256 bad signature", both, signRS256(t, otherKey, rs256, validClaims(nil)), "bad signature"},
+		{"tampered claims", both, func() string {
+			parts := strings.Split(signHS256(t, testHMACSecret, hs256, validClaims(nil)), ".")
+			forged := strings.Split(encodeJWT(t, hs256, validClaims(map[string]any{"sub": "mallory"})), ".")
+			return parts[0] + "." + forged[1] + "." + parts[2]
+		}(), "bad signature"},
+		{"alg none", both, encodeJWT(t, map[string]any{"alg": "none"}, validClaims(nil)) + ".", "unsupported algorithm"},
+		{"alg None", both, encodeJWT(t, map[string]any{"alg": "None"}, validClaims(nil)) + ".", "unsupported algorithm"},
+		{"HS256 signed with the RSA public key", rsaOnly, signHS256(t, publicDER, hs256, validClaims(nil)), "HS256 tokens are not accepted"},
+		{"RS256 without RSA keys", &jwtVerifier{hmacSecret: testHMACSecret}, signRS256(t, rsaKey, rs256, validClaims(nil)), "RS256 tokens are not accepted"},
+		{"unknown kid", both, signRS256(t, rsaKey, map[string]any{"alg": "RS256", "kid": "k9"}, validClaims(nil)), "unknown key id"},
+		{"no kid and several keys", twoKeys, signRS256(t, rsaKey, map[string]any{"alg": "RS256"}, validClaims(nil)), "unknown key id"},
+
+		{"expired within the leeway", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"exp": testNow.Add(-jwtLeeway).Unix()})), ""},
+		{"expired past the leeway", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"exp": testNow.Add(-jwtLeeway - time.Second).Unix()})), "token expired"},
+		{"not before within the leeway", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"nbf": testNow.Add(jwtLeeway).Unix()})), ""},
+		{"not before past the leeway", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"nbf": testNow.Add(jwtLeeway + time.Second).Unix()})), "token not valid yet"},
+		{"fractional exp", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"exp": float64(testNow.Add(-jwtLeeway).Unix()) + 0.5})), ""},
+		{"missing exp", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"exp": nil})), "missing exp claim"},
+		{"missing nbf", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"nbf": nil})), ""},
+
+		{"wrong issuer", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"iss": "https://evil.example"})), "unexpected issuer"},
+		{"missing issuer", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"iss": nil})), "unexpected issuer"},
+		{"wrong audience", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"aud": "someone-else"})), "not for this audience"},
+		{"single audience string", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"aud": "routeguide"})), ""},
+		{"missing audience", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"aud": nil})), "not for this audience"},
+		{"missing sub", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"sub": nil})), "missing sub claim"},
+		{"empty sub", both, signHS256(t, testHMACSecret, hs256, validClaims(map[string]any{"sub": ""})), "missing sub claim"},
+
+		{"two parts", both, "abc.def", "malformed token"},
+		{"bad header encoding", both, "!!!.e30.", "malformed header"},
+		{"bad signature encoding", both, encodeJWT(t, hs256, validClaims(nil)) + ".!!!", "malformed signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.verifier.verify(tt.token, testNow)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verify: %v", err)
+				}
+				if claims.Subject != "alice" {
+					t.Errorf("subject %q, want alice", claims.Subject)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("verify error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// streamRetryDelay is the retry hint for a caller at its stream limit,
+	// which frees up only when one of its streams ends
+	streamRetryDelay = time.Second
+
+	// bucketSweepInterval is how often idle rate limit buckets are dropped
+	bucketSweepInterval = time.Minute
+)
+
+// Rejected calls and messages, published at /debug/vars when -debug-addr is set
+var callsLimited = expvar.NewMap("routeguide_calls_limited") // by reason: rate, streams, messages
+
+// tokenBucket allows bursts of up to burst events, refilled at rate per second
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{tokens: burst, last: now}
+}
+
+// take spends a token if one is available, and otherwise returns how long
+// until one will be
+func (b *tokenBucket) take(rate, burst float64, now time.Time) (bool, time.Duration) {
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// limiter protects the server from callers that start too many calls, hold
+// too many streams open or send too many messages on a stream. Callers are
+// told apart by their authenticated identity or else their peer address.
+// A zero rate or count disables that limit.
+type limiter struct {
+	rate       float64 // calls per second each caller may start per method
+	burst      float64
+	maxStreams int     // streams each caller may have open at once
+	msgRate    float64 // messages per second a client may send on each stream
+	msgBurst   float64
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*tokenBucket
+	streams   map[string]int // open streams by caller
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	caller, method string
+}
+
+func newLimiter(cfg *config) *limiter {
+	return &limiter{
+		rate:       cfg.rateLimit,
+		burst:      float64(cfg.rateBurst),
+		maxStreams: cfg.maxStreams,
+		msgRate:    cfg.streamMsgRate,
+		msgBurst:   float64(cfg.streamMsgBurst),
+		buckets:    make(map[bucketKey]*tokenBucket),
+		streams:    make(map[string]int),
+		lastSweep:  time.Now(),
+	}
+}
+
+// callerKey names the caller of the RPC in ctx for accounting
+func callerKey(ctx context.Context) string {
+	if id := identityFromContext(ctx); id != nil {
+		return "user:" + id.subject
+	}
+	if pr, ok := peer.FromContext(ctx); ok {
+		// Connections from one host share its limits whatever their port
+		if host, _, err := net.SplitHostPort(pr.Addr.String()); err == nil {
+			return "ip:" + host
+		}
+		return "peer:" + pr.Addr.String()
+	}
+	return "unknown"
+}
+
+// allowCall spends one of caller's tokens for method
+func (l *limiter) allowCall(caller, method string) error {
+	if l.rate <= 0 {
+		return nil
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= bucketSweepInterval {
+		l.sweep(now)
+	}
+	key := bucketKey{caller, method}
+	b := l.buckets[key]
+	if b == nil {
+		b = newTokenBucket(l.burst, now)
+		l.buckets[key] = b
+	}
+	ok, wait := b.take(l.rate, l.burst, now)
+	if !ok {
+		callsLimited.Add("rate", 1)
+		slog.Debug("rate limited call", "caller", caller, "method", method, "retry_after", wait)
+		return resourceExhausted(wait, "too many %s calls; retry after %v", method, wait.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// sweep drops the buckets that have refilled completely, which are
+// indistinguishable from new ones; l.mu must be held
+func (l *limiter) sweep(now time.Time) {
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// openStream counts a new stream for caller; the returned release must be
+// called when it ends
+func (l *limiter) openStream(caller string) (release func(), err error) {
+	if l.maxStreams <= 0 {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.streams[caller] >= l.maxStreams {
+		callsLimited.Add("streams", 1)
+		slog.Debug("stream limit reached", "caller", caller, "open", l.streams[caller])
+		return nil, resourceExhausted(streamRetryDelay, "too many open streams; at most %d are allowed", l.maxStreams)
+	}
+	l.streams[caller]++
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.streams[caller]--; l.streams[caller] == 0 {
+			delete(l.streams, caller)
+		}
+	}, nil
+}
+
+func (l *limiter) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := l.allowCall(callerKey(ctx), info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l *limiter) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	caller := callerKey(ss.Context())
+	if err := l.allowCall(caller, info.FullMethod); err != nil {
+		return err
+	}
+	release, err := l.openStream(caller)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if info.IsClientStream && l.msgRate > 0 {
+		ss = &limitedStream{ServerStream: ss, limiter: l, bucket: newTokenBucket(l.msgBurst, time.Now())}
+	}
+	return handler(srv, ss)
+}
+
+// limitedStream fails the stream once the client sends messages faster than
+// the limiter's message rate allows
+type limitedStream struct {
+	grpc.ServerStream
+	limiter *limiter
+	bucket  *tokenBucket // only touched by RecvMsg, which is never called concurrently
+}
+
+func (s *limitedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	ok, wait := s.bucket.take(s.limiter.msgRate, s.limiter.msgBurst, time.Now())
+	if !ok {
+		callsLimited.Add("messages", 1)
+		return resourceExhausted(wait, "sending faster than %v messages per second", s.limiter.msgRate)
+	}
+	return nil
+}
+
+// resourceExhausted returns a ResourceExhausted error telling the client how
+// long to back off before retrying
+func resourceExhausted(wait time.Duration, format string, args ...any) error {
+	st := status.Newf(codes.ResourceExhausted, format, args...)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	pb "routeguide/routeguide"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// retryDelay returns the RetryInfo delay of a ResourceExhausted error
+func retryDelay(t *testing.T, err error) time.Duration {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("got %v, want ResourceExhausted", err)
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.RetryDelay.AsDuration()
+		}
+	}
+	t.Fatalf("%v has no RetryInfo", err)
+	return 0
+}
+
+func TestTokenBucket(t *testing.T) {
+	const rate, burst = 2, 3
+	start := time.Unix(1_700_000_000, 0)
+	b := newTokenBucket(burst, start)
+	for i := range burst {
+		if ok, _ := b.take(rate, burst, start); !ok {
+			t.Fatalf("take %d of the burst refused", i+1)
+		}
+	}
+	steps := []struct {
+		after    time.Duration
+		wantOK   bool
+		wantWait time.Duration
+	}{
+		{0, false, 500 * time.Millisecond},
+		{250 * time.Millisecond, false, 250 * time.Millisecond},
+		{500 * time.Millisecond, true, 0},
+		{500 * time.Millisecond, false, 500 * time.Millisecond},
+		{time.Second, true, 0},
+	}
+	for _, step := range steps {
+		ok, wait := b.take(rate, burst, start.Add(step.after))
+		if ok != step.wantOK || wait != step.wantWait {
+			t.Errorf("take at +%v = %v, %v; want %v, %v", step.after, ok, wait, step.wantOK, step.wantWait)
+		}
+	}
+
+	// An idle bucket refills to the burst and no further
+	later := start.Add(time.Hour)
+	for i := range burst {
+		if ok, _ := b.take(rate, burst, later); !ok {
+			t.Fatalf("take %d after refilling refused", i+1)
+		}
+	}
+	if ok, _ := b.take(rate, burst, later); ok {
+		t.Error("bucket refilled past its burst")
+	}
+}
+
+func TestAllowCall(t *testing.T) {
+	l := newLimiter(&config{rateLimit: 1, rateBurst: 2})
+	for i := range 2 {
+		if err := l.allowCall("user:alice", getFeature); err != nil {
+			t.Fatalf("call %d of the burst: %v", i+1, err)
+		}
+	}
+	err := l.allowCall("user:alice", getFeature)
+	if wait := retryDelay(t, err); wait <= 0 || wait > time.Second {
+		t.Errorf("retry delay %v, want up to the 1s refill time", wait)
+	}
+
+	// Each caller and method has its own bucket
+	if err := l.allowCall("user:bob", getFeature); err != nil {
+		t.Errorf("another caller was limited: %v", err)
+	}
+	if err := l.allowCall("user:alice", routeChat); err != nil {
+		t.Errorf("another method was limited: %v", err)
+	}
+}
+
+func TestOpenStream(t *testing.T) {
+	l := newLimiter(&config{maxStreams: 2})
+	first, err := l.openStream("user:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := l.openStream("user:alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = l.openStream("user:alice")
+	if wait := retryDelay(t, err); wait != streamRetryDelay {
+		t.Errorf("retry delay %v, want %v", wait, streamRetryDelay)
+	}
+	if _, err := l.openStream("user:bob"); err != nil {
+		t.Errorf("another caller was limited: %v", err)
+	}
+
+	first()
+	third, err := l.openStream("user:alice")
+	if err != nil {
+		t.Fatalf("stream refused after one was released: %v", err)
+	}
+	second()
+	third()
+	if n, ok := l.streams["user:alice"]; ok {
+		t.Errorf("%d streams still counted after all were released", n)
+	}
+}
+
+// A zero rate or count turns that limit off
+func TestZeroLimitsDisabled(t *testing.T) {
+	l := newLimiter(&config{rateBurst: 1, streamMsgBurst: 1})
+	for range 1000 {
+		if err := l.allowCall("user:alice", getFeature); err != nil {
+			t.Fatalf("call limited with a zero rate: %v", err)
+		}
+		if _, err := l.openStream("user:alice"); err != nil {
+			t.Fatalf("stream limited with a zero count: %v", err)
+		}
+	}
+
+	client := newTestClient(t, grpc.ChainStreamInterceptor(l.streamInterceptor))
+	stream, err := client.RecordRoute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 100 {
+		if err := stream.Send(pt(0, 0)); err != nil {
+			break
+		}
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		t.Errorf("messages limited with a zero rate: %v", err)
+	}
+
+	if (&config{}).limitsEnabled() {
+		t.Error("limits enabled by default")
+	}
+}
+
+func TestCallerKey(t *testing.T) {
+	withPeer := func(addr net.Addr) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	}
+	tcp := func(ip string, port int) net.Addr { return &net.TCPAddr{IP: net.ParseIP(ip), Port: port} }
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"authenticated", withIdentity(withPeer(tcp("10.0.0.1", 1234)), &identity{subject: "alice"}), "user:alice"},
+		{"anonymous over TCP", withPeer(tcp("10.0.0.1", 1234)), "ip:10.0.0.1"},
+		{"another port of the same host", withPeer(tcp("10.0.0.1", 5678)), "ip:10.0.0.1"},
+		{"IPv6", withPeer(tcp("2001:db8::1", 1234)), "ip:2001:db8::1"},
+		{"Unix socket", withPeer(&net.UnixAddr{Name: "/run/rg.sock", Net: "unix"}), "peer:/run/rg.sock"},
+		{"no peer", context.Background(), "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callerKey(tt.ctx); got != tt.want {
+				t.Errorf("callerKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterInterceptors(t *testing.T) {
+	l := newLimiter(&config{maxStreams: 1, streamMsgRate: 1, streamMsgBurst: 3})
+	client := newTestClient(t, grpc.ChainStreamInterceptor(l.streamInterceptor))
+
+	// The message limit ends a stream that sends faster than it allows
+	stream, err := client.RecordRoute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		if err := stream.Send(pt(0, 0)); err != nil {
+			break
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	if wait := retryDelay(t, err); wait <= 0 || wait > time.Second {
+		t.Errorf("retry delay %v, want up to the 1s refill time", wait)
+	}
+
+	// The stream count is given back when a stream ends, so streams one
+	// after another are never limited
+	rect := &pb.ListFeaturesRequest{BottomLeftCorner: pt(-90e7, -180e7), TopRightCorner: pt(90e7, 180e7)}
+	for i := range 3 {
+		features, err := client.ListFeatures(context.Background(), rect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for err == nil {
+			_, err = features.Recv()
+		}
+		if err != io.EOF {
+			t.Fatalf("stream %d: %v", i+1, err)
+		}
+	}
+
+	// While one is open, a second is refused. The open stream receiving
+	// history shows that its handler is running and counted.
+	post, err := client.RouteChat(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := post.Send(&pb.RouteNote{Location: pt(1, 2), Message: "history"}); err != nil {
+		t.Fatal(err)
+	}
+	post.CloseSend()
+	for err == nil {
+		_, err = post.Recv()
+	}
+	open, err := client.RouteChat(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := open.Send(&pb.RouteNote{Location: pt(1, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := open.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	second, err := client.RouteChat(ctx)
+	if err == nil {
+		_, err = second.Recv()
+	}
+	if wait := retryDelay(t, err); wait != streamRetryDelay {
+		t.Errorf("retry delay %v, want %v", wait, streamRetryDelay)
+	}
+	open.CloseSend()
+}
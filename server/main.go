@@ -2,51 +2,146 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
-	"log"
-	"net"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
 	pb "routeguide/routeguide"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // routeGuideServer implements the RouteGuideServer interface
 type routeGuideServer struct {
 	pb.UnimplementedRouteGuideServer
-	savedFeatures []*pb.Feature              // in-memory storage for geographical features
-	routeNotes    map[string][]*pb.RouteNote // in-memory storage for map of route notes at each point; use serialized point as the key
-	mu            sync.Mutex                 // mutex for thread-safe access to routeNotes
+	features    atomic.Pointer[featureSet]              // current feature catalogue snapshot; replaced wholesale on every change
+	featuresMu  sync.Mutex                              // serializes catalogue writers: reloads and the admin RPCs
+	notes       NoteStore                               // route notes posted through RouteChat
+	subscribers map[string]map[*chatSubscriber]struct{} // open RouteChat streams that joined each serialized point
+	mu          sync.Mutex                              // mutex for thread-safe access to notes and subscribers
 }
 
-// findFeatureAtPoint checks if a point exists in the saved features list
-// Returns the feature if found, nil otherwise
-func (s *routeGuideServer) findFeatureAtPoint(point *pb.Point) *pb.Feature {
-	for _, feature := range s.savedFeatures {
-		if proto.Equal(feature.Location, point) {
-			return feature
-		}
-	}
-	return nil
+// snapshot returns the feature catalogue currently being served.
+// Handlers load it once per call so a concurrent reload cannot change
+// the catalogue underneath them.
+func (s *routeGuideServer) snapshot() *featureSet {
+	return s.features.Load()
+}
+
+// setFeatures atomically replaces the served feature catalogue
+func (s *routeGuideServer) setFeatures(features []*pb.Feature) {
+	s.featuresMu.Lock()
+	defer s.featuresMu.Unlock()
+	s.features.Store(newFeatureSet(features))
 }
 
 // GetFeature retrieves a feature at the given geographical point
 // Returns the named feature if found, otherwise returns a feature with empty name
 func (s *routeGuideServer) GetFeature(_ context.Context, point *pb.Point) (*pb.Feature, error) {
-	if feature := s.findFeatureAtPoint(point); feature != nil {
+	if err := validatePoint(point); err != nil {
+		return nil, err
+	}
+	if feature := s.snapshot().findFeatureAtPoint(point); feature != nil {
 		return feature, nil
 	}
 	return &pb.Feature{Location: point}, nil
 }
 
-func (s *routeGuideServer) ListFeatures(rect *pb.Rectangle, stream pb.RouteGuide_ListFeaturesServer) error {
-	for _, feature := range s.savedFeatures {
-		if isFeatureInRectangle(rect, feature) {
-			if err := stream.Send(feature); err != nil {
-				return err
+func (s *routeGuideServer) ListFeatures(req *pb.ListFeaturesRequest, stream pb.RouteGuide_ListFeaturesServer) error {
+	rect := requestRectangle(req)
+	filter, cursor, err := validateListFeaturesRequest(req)
+	if err != nil {
+		return err
+	}
+
+	remaining := -1 // features max_results still allows; -1 means no limit
+	if req.MaxResults > 0 {
+		remaining = int(max(req.MaxResults-cursor.Sent, 0))
+	}
+	page := int(min(req.PageSize, maxPageSize))
+
+	sent := 0
+	var last sortKey
+	s.snapshot().ordered(req.OrderBy, req.ReferencePoint, rectangleBounds(rect), cursor.After, func(feature *pb.Feature, key sortKey) bool {
+		if !isFeatureInRectangle(rect, feature) || !filter.matches(feature) {
+			return true
+		}
+		if sent == remaining {
+			return false
+		}
+		if page > 0 && sent == page {
+			// Another feature matches, so there is a next page
+			next := pageCursor{After: &last, Sent: cursor.Sent + int32(sent), Fingerprint: requestFingerprint(req)}
+			stream.SetTrailer(metadata.Pairs(nextPageTokenKey, next.encode()))
+			return false
+		}
+		err = stream.Send(feature)
+		sent++
+		last = key
+		return err == nil
+	})
+	return err
+}
+
+// requestRectangle returns the rectangle part of a ListFeatures request
+func requestRectangle(req *pb.ListFeaturesRequest) *pb.Rectangle {
+	return &pb.Rectangle{
+		BottomLeftCorner:    req.BottomLeftCorner,
+		TopRightCorner:      req.TopRightCorner,
+		CrossesAntimeridian: req.CrossesAntimeridian,
+	}
+}
+
+// ListFeaturesInRadius streams every feature whose great-circle distance
+// from the circle's center is at most radius_m
+func (s *routeGuideServer) ListFeaturesInRadius(circle *pb.Circle, stream pb.RouteGuide_ListFeaturesInRadiusServer) error {
+	if err := validateCircle(circle); err != nil {
+		return err
+	}
+	return s.streamFeatures(circleBounds(circle), func(feature *pb.Feature) bool {
+		return distance(circle.Center, feature.Location) <= circle.RadiusM
+	}, stream)
+}
+
+// ListFeaturesInPolygon streams every feature inside the polygon,
+// including features on its edges
+func (s *routeGuideServer) ListFeaturesInPolygon(p *pb.Polygon, stream pb.RouteGuide_ListFeaturesInPolygonServer) error {
+	poly, err := newPolygon(p)
+	if err != nil {
+		return err
+	}
+	return s.streamFeatures([]bounds{poly.bounds}, func(feature *pb.Feature) bool {
+		return poly.contains(feature.Location)
+	}, stream)
+}
+
+// streamFeatures sends every feature that lies in one of boxes and satisfies
+// match. The boxes only narrow down candidates via the spatial index; match
+// has the final say, so boxes may safely over-cover the query area.
+func (s *routeGuideServer) streamFeatures(boxes []bounds, match func(*pb.Feature) bool, stream grpc.ServerStreamingServer[pb.Feature]) error {
+	index := s.snapshot().index
+	var err error
+	for _, b := range boxes {
+		index.search(b, func(feature *pb.Feature) bool {
+			if !match(feature) {
+				return true
 			}
+			err = stream.Send(feature)
+			return err == nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -54,6 +149,10 @@ func (s *routeGuideServer) ListFeatures(rect *pb.Rectangle, stream pb.RouteGuide
 
 func (s *routeGuideServer) RecordRoute(stream pb.RouteGuide_RecordRouteServer) error {
 	var point_count, feature_count int32
+	var total_distance float64
+	var last_point *pb.Point
+	var first_time, last_time time.Time
+	features := s.snapshot()
 
 	for {
 		point, err := stream.Recv()
@@ -61,27 +160,98 @@ func (s *routeGuideServer) RecordRoute(stream pb.RouteGuide_RecordRouteServer) e
 			return stream.SendAndClose(&pb.RouteSummary{
 				PointCount:   point_count,
 				FeatureCount: feature_count,
+				Distance:     int32(math.Round(total_distance)),
+				ElapsedTime:  int32(last_time.Sub(first_time) / time.Second),
 			})
 		}
 		if err != nil {
 			return err
 		}
+		if err := validateRoutePoint(point_count, point); err != nil {
+			return err
+		}
 
+		last_time = time.Now()
+		if point_count == 0 {
+			first_time = last_time
+		}
 		point_count = point_count + 1
-		if s.findFeatureAtPoint(point) != nil {
+		if features.findFeatureAtPoint(point) != nil {
 			feature_count = feature_count + 1
 		}
+		if last_point != nil {
+			total_distance += distance(last_point, point)
+		}
+		last_point = point
 	}
 }
 
-// Receives a stream of Route Notes, which is Point Message pair, and returns back
-// stream of all route notes at that location
+// NearestFeatures streams up to k features closest to the requested point,
+// nearest first, stopping early at max_distance_m when it is set
+func (s *routeGuideServer) NearestFeatures(req *pb.NearestRequest, stream pb.RouteGuide_NearestFeaturesServer) error {
+	if err := validateNearestRequest(req); err != nil {
+		return err
+	}
+
+	var sent int32
+	var err error
+	s.snapshot().index.nearest(req.Point, nil, 0, func(feature *pb.Feature, dist float64) bool {
+		if req.MaxDistanceM > 0 && dist > req.MaxDistanceM {
+			return false
+		}
+		err = stream.Send(&pb.FeatureDistance{Feature: feature, DistanceM: dist})
+		sent++
+		return err == nil && sent < req.K
+	})
+	return err
+}
 
 func serialize(point *pb.Point) string {
 	return fmt.Sprintf("%d,%d", point.Latitude, point.Longitude)
 }
 
+// RouteChat receives a stream of Route Notes, which is Point Message pair. The first
+// note at a location joins it and returns back the route notes already stored there;
+// after that the stream only receives new notes that other RouteChat streams post at
+// the location. A note with an empty message joins the location without posting anything.
 func (s *routeGuideServer) RouteChat(stream pb.RouteGuide_RouteChatServer) error {
+	sub := newChatSubscriber()
+	defer s.leave(sub)
+
+	// gRPC streams do not allow concurrent Sends, so notes are received on a
+	// separate goroutine and every outgoing note is written from this one
+	recvErr := make(chan error, 1)
+	go func() { recvErr <- s.receiveNotes(stream, sub) }()
+
+	send := func() error {
+		for _, note := range sub.take() {
+			if err := stream.Send(note); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for {
+		select {
+		case <-sub.ready:
+			if err := send(); err != nil {
+				return err
+			}
+		case <-sub.evicted:
+			return status.Error(codes.ResourceExhausted, "RouteChat stream is not keeping up with incoming notes")
+		case err := <-recvErr:
+			if err != nil {
+				return err
+			}
+			// The client is done sending; flush what is already queued and finish
+			return send()
+		}
+	}
+}
+
+// receiveNotes reads notes from stream until the client closes its side,
+// joining sub to each note's location and storing and relaying posted notes
+func (s *routeGuideServer) receiveNotes(stream pb.RouteGuide_RouteChatServer, sub *chatSubscriber) error {
 	for {
 
 		// 1. Process route note
@@ -92,92 +262,171 @@ func (s *routeGuideServer) RouteChat(stream pb.RouteGuide_RouteChatServer) error
 		if err != nil {
 			return err
 		}
+		if err := validateRouteNote(note); err != nil {
+			return err
+		}
 
 		// 2. serialize note using the location
 		key := serialize(note.Location)
 
-		// 3. Lock
+		// 3. Lock, and replay the location's history if this stream is new there
 		s.mu.Lock()
-		// 4. add to route notes
-		s.routeNotes[key] = append(s.routeNotes[key], note)
-
-		// 5. create copy
-		rn := make([]*pb.RouteNote, len(s.routeNotes[key]))
-		copy(rn, s.routeNotes[key])
-		s.mu.Unlock()
+		s.join(sub, key, note.ResumeAfter)
 
-		// 6. write stream
-		for _, note := range rn {
-			if err := stream.Send(note); err != nil {
-				return err
+		// 4. add to route notes and relay to the other participants
+		if note.Message != "" {
+			note.Author = ""
+			if id := identityFromContext(stream.Context()); id != nil {
+				note.Author = id.subject
+			}
+			if err := s.notes.append(key, note); err != nil {
+				s.mu.Unlock()
+				slog.Error("storing route note failed", "err", err)
+				return status.Error(codes.Internal, "failed to store route note")
 			}
+			s.broadcast(sub, key, note)
 		}
+		s.mu.Unlock()
 
 	}
 
 }
 
-func isFeatureInRectangle(rect *pb.Rectangle, feature *pb.Feature) bool {
-	lat := feature.Location.Latitude
-	lon := feature.Location.Longitude
+// rectangleBounds normalizes rect into inclusive boxes: latitudes and
+// longitudes are each taken as the min/max of the two corners, and a
+// rectangle crossing the antimeridian becomes one box on either side of it
+func rectangleBounds(rect *pb.Rectangle) []bounds {
+	lo, hi := rect.BottomLeftCorner, rect.TopRightCorner
+	minLat, maxLat := min(lo.Latitude, hi.Latitude), max(lo.Latitude, hi.Latitude)
+	minLon, maxLon := min(lo.Longitude, hi.Longitude), max(lo.Longitude, hi.Longitude)
+
+	if rect.CrossesAntimeridian {
+		return []bounds{
+			{minLat: minLat, maxLat: maxLat, minLon: maxLon, maxLon: maxLongitudeE7},
+			{minLat: minLat, maxLat: maxLat, minLon: -maxLongitudeE7, maxLon: minLon},
+		}
+	}
+	return []bounds{{minLat: minLat, maxLat: maxLat, minLon: minLon, maxLon: maxLon}}
+}
 
-	return lat > rect.BottomLeftCorner.Latitude && lat < rect.TopRightCorner.Latitude &&
-		lon > rect.BottomLeftCorner.Longitude && lon < rect.TopRightCorner.Longitude
+func isFeatureInRectangle(rect *pb.Rectangle, feature *pb.Feature) bool {
+	for _, b := range rectangleBounds(rect) {
+		if b.contains(feature.Location) {
+			return true
+		}
+	}
+	return false
 }
 
 // newServer creates and initializes a new RouteGuide server instance
-// with hardcoded Liberty Bell coordinates as sample data
-func newServer() *routeGuideServer {
-	return &routeGuideServer{
-		savedFeatures: []*pb.Feature{
-			{
-				Name:     "Liberty Bell",
-				Location: &pb.Point{Latitude: 395906000, Longitude: -753506000},
-			},
-			{
-				Name:     "Statue of Liberty",
-				Location: &pb.Point{Latitude: 405847500, Longitude: -741301800},
-			},
-			{
-				Name:     "Empire State Building",
-				Location: &pb.Point{Latitude: 407486500, Longitude: -739885900},
-			},
-			{
-				Name:     "Golden Gate Bridge",
-				Location: &pb.Point{Latitude: 378197400, Longitude: -1224650700},
-			},
-			{
-				Name:     "Lincoln Memorial",
-				Location: &pb.Point{Latitude: 389030600, Longitude: -770494800},
-			},
-			{
-				Name:     "Mount Rushmore",
-				Location: &pb.Point{Latitude: 438813500, Longitude: -1031032800},
-			},
-			{
-				Name:     "Space Needle",
-				Location: &pb.Point{Latitude: 476203100, Longitude: -1221315600},
-			},
-		},
-		routeNotes: make(map[string][]*pb.RouteNote),
+// serving the given feature catalogue and keeping route notes in notes
+func newServer(features []*pb.Feature, notes NoteStore) *routeGuideServer {
+	s := &routeGuideServer{
+		notes:       notes,
+		subscribers: make(map[string]map[*chatSubscriber]struct{}),
 	}
+	s.setFeatures(features)
+	return s
 }
 
 func main() {
-	// Create TCP listener on port 50051
-	lis, err := net.Listen("tcp", ":50051")
+	cfg, err := loadConfig(flag.CommandLine, os.Args[1:])
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+	slog.SetDefault(cfg.logger())
+
+	// Load the feature catalogue, falling back to the demo dataset
+	features := demoFeatures()
+	if cfg.features != "" {
+		features, err = loadFeatures(cfg.features)
+		if err != nil {
+			fatal("failed to load features", err)
+		}
+		slog.Info("loaded features", "count", len(features), "path", cfg.features)
+	}
+
+	lis, err := cfg.listener()
+	if err != nil {
+		fatal("failed to listen", err)
 	}
 
 	// Create gRPC server and register our RouteGuide service
-	s := grpc.NewServer()
-	pb.RegisterRouteGuideServer(s, newServer())
+	opts, err := cfg.serverOptions()
+	if err != nil {
+		fatal("failed to configure server", err)
+	}
+	s := grpc.NewServer(opts...)
+	notes, err := openNoteStore(cfg)
+	if err != nil {
+		fatal("failed to open note store", err)
+	}
+	server := newServer(features, notes)
+	pb.RegisterRouteGuideServer(s, server)
+	go server.runJanitor(cfg.notesTTL, cfg.janitorInterval)
+
+	// Stop cleanly on SIGINT/SIGTERM so buffered notes reach the disk
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+		slog.Info("shutting down")
+		// Long-lived RouteChat streams would hold up a graceful stop forever
+		timer := time.AfterFunc(10*time.Second, s.Stop)
+		s.GracefulStop()
+		timer.Stop()
+	}()
 
-	log.Println("Server listening on :50051")
+	if cfg.debugAddr != "" {
+		go func() {
+			slog.Info("serving metrics", "url", "http://"+cfg.debugAddr+"/debug/vars")
+			if err := http.ListenAndServe(cfg.debugAddr, nil); err != nil {
+				slog.Error("metrics server failed", "err", err)
+			}
+		}()
+	}
+
+	// Reload the catalogue on SIGHUP or when the features file changes
+	if cfg.features != "" {
+		go server.watchFeatures(cfg.features, cfg.watch)
+	}
+
+	slog.Info("server listening", "addr", lis.Addr().String(), "tls", cfg.tlsCert != "", "mtls", cfg.tlsClientCA != "",
+		"auth", cfg.authEnabled(), "policy", cfg.authPolicy != "")
 
 	// Start serving requests
 	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		fatal("failed to serve", err)
+	}
+	if err := notes.close(); err != nil {
+		fatal("failed to close note store", err)
+	}
+}
+
+// fatal logs a startup or shutdown failure and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// openNoteStore opens the route note store selected by the notes-* settings
+func openNoteStore(cfg *config) (NoteStore, error) {
+	retention := retentionPolicy{
+		maxPerLocation: cfg.notesPerLocation,
+		maxTotal:       cfg.notesMax,
+		maxAge:         cfg.notesTTL,
+	}
+	if cfg.notesDir == "" {
+		return newMemoryNoteStore(retention), nil
+	}
+	fsync, err := parseFsyncPolicy(cfg.notesFsync)
+	if err != nil {
+		return nil, err
 	}
+	return openWALNoteStore(cfg.notesDir, retention, walOptions{
+		fsync:         fsync,
+		fsyncInterval: cfg.notesFsyncInterval,
+		snapshotEvery: cfg.notesSnapshotEvery,
+	})
 }
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"net"
+	pb "routeguide/routeguide"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func pt(lat, lon int32) *pb.Point {
+	return &pb.Point{Latitude: lat, Longitude: lon}
+}
+
+// newTestClient serves the demo features in memory with the given server
+// options and returns a client connected to them; both are shut down when
+// the test ends
+func newTestClient(t *testing.T, opts ...grpc.ServerOption) pb.RouteGuideClient {
+	t.Helper()
+	return serveTestClient(t, newServer(demoFeatures(), newMemoryNoteStore(retentionPolicy{})), opts...)
+}
+
+// serveTestClient serves rg in memory and returns a client connected to it
+func serveTestClient(t *testing.T, rg *routeGuideServer, opts ...grpc.ServerOption) pb.RouteGuideClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(opts...)
+	pb.RegisterRouteGuideServer(s, rg)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewRouteGuideClient(conn)
+}
+
+func TestIsFeatureInRectangle(t *testing.T) {
+	// 40°N..41°N, 75°W..74°W
+	box := &pb.Rectangle{BottomLeftCorner: pt(40e7, -75e7), TopRightCorner: pt(41e7, -74e7)}
+	// 10°S..10°N, from 170°E eastwards across the antimeridian to 170°W
+	wrap := &pb.Rectangle{BottomLeftCorner: pt(-10e7, 170e7), TopRightCorner: pt(10e7, -170e7), CrossesAntimeridian: true}
+
+	tests := []struct {
+		name  string
+		rect  *pb.Rectangle
+		point *pb.Point
+		want  bool
+	}{
+		{"inside", box, pt(405e6, -745e6), true},
+		{"outside north", box, pt(41e7+1, -745e6), false},
+		{"outside south", box, pt(40e7-1, -745e6), false},
+		{"outside east", box, pt(405e6, -74e7+1), false},
+		{"outside west", box, pt(405e6, -75e7-1), false},
+
+		{"on north edge", box, pt(41e7, -745e6), true},
+		{"on south edge", box, pt(40e7, -745e6), true},
+		{"on east edge", box, pt(405e6, -74e7), true},
+		{"on west edge", box, pt(405e6, -75e7), true},
+		{"on corner", box, pt(41e7, -75e7), true},
+
+		{"reversed corners inside", &pb.Rectangle{BottomLeftCorner: pt(41e7, -74e7), TopRightCorner: pt(40e7, -75e7)}, pt(405e6, -745e6), true},
+		{"reversed corners outside", &pb.Rectangle{BottomLeftCorner: pt(41e7, -74e7), TopRightCorner: pt(40e7, -75e7)}, pt(42e7, -745e6), false},
+		{"mixed corners inside", &pb.Rectangle{BottomLeftCorner: pt(40e7, -74e7), TopRightCorner: pt(41e7, -75e7)}, pt(405e6, -745e6), true},
+		{"reversed corners on edge", &pb.Rectangle{BottomLeftCorner: pt(41e7, -74e7), TopRightCorner: pt(40e7, -75e7)}, pt(40e7, -74e7), true},
+
+		{"point rectangle at the point", &pb.Rectangle{BottomLeftCorner: pt(1, 2), TopRightCorner: pt(1, 2)}, pt(1, 2), true},
+		{"point rectangle elsewhere", &pb.Rectangle{BottomLeftCorner: pt(1, 2), TopRightCorner: pt(1, 2)}, pt(1, 3), false},
+		{"line rectangle on the line", &pb.Rectangle{BottomLeftCorner: pt(0, -10), TopRightCorner: pt(0, 10)}, pt(0, 5), true},
+		{"line rectangle off the line", &pb.Rectangle{BottomLeftCorner: pt(0, -10), TopRightCorner: pt(0, 10)}, pt(1, 5), false},
+
+		{"antimeridian east side", wrap, pt(0, 175e7), true},
+		{"antimeridian west side", wrap, pt(0, -175e7), true},
+		{"antimeridian at +180", wrap, pt(0, maxLongitudeE7), true},
+		{"antimeridian at -180", wrap, pt(0, -maxLongitudeE7), true},
+		{"antimeridian on east-side edge", wrap, pt(0, 170e7), true},
+		{"antimeridian on west-side edge", wrap, pt(0, -170e7), true},
+		{"antimeridian in the gap", wrap, pt(0, 0), false},
+		{"antimeridian just past the east-side edge", wrap, pt(0, 170e7-1), false},
+		{"antimeridian just past the west-side edge", wrap, pt(0, -170e7+1), false},
+		{"antimeridian outside latitude", wrap, pt(11e7, 175e7), false},
+		{"antimeridian with reversed corners", &pb.Rectangle{BottomLeftCorner: pt(10e7, -170e7), TopRightCorner: pt(-10e7, 170e7), CrossesAntimeridian: true}, pt(0, 179e7), true},
+		{"same corners without crossing", &pb.Rectangle{BottomLeftCorner: pt(-10e7, 170e7), TopRightCorner: pt(10e7, -170e7)}, pt(0, 0), true},
+		{"same corners without crossing, near ±180", &pb.Rectangle{BottomLeftCorner: pt(-10e7, 170e7), TopRightCorner: pt(10e7, -170e7)}, pt(0, 175e7), false},
+		{"antimeridian degenerate longitude", &pb.Rectangle{BottomLeftCorner: pt(0, 5e7), TopRightCorner: pt(1e7, 5e7), CrossesAntimeridian: true}, pt(5e6, -100e7), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feature := &pb.Feature{Location: tt.point}
+			if got := isFeatureInRectangle(tt.rect, feature); got != tt.want {
+				t.Errorf("isFeatureInRectangle(%v, %v) = %v, want %v", tt.rect, tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRectangleBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		rect *pb.Rectangle
+		want []bounds
+	}{
+		{
+			"ordered corners",
+			&pb.Rectangle{BottomLeftCorner: pt(1, 2), TopRightCorner: pt(3, 4)},
+			[]bounds{{minLat: 1, minLon: 2, maxLat: 3, maxLon: 4}},
+		},
+		{
+			"reversed corners",
+			&pb.Rectangle{BottomLeftCorner: pt(3, 4), TopRightCorner: pt(1, 2)},
+			[]bounds{{minLat: 1, minLon: 2, maxLat: 3, maxLon: 4}},
+		},
+		{
+			"degenerate",
+			&pb.Rectangle{BottomLeftCorner: pt(5, 6), TopRightCorner: pt(5, 6)},
+			[]bounds{{minLat: 5, minLon: 6, maxLat: 5, maxLon: 6}},
+		},
+		{
+			"crossing the antimeridian",
+			&pb.Rectangle{BottomLeftCorner: pt(-1, 170e7), TopRightCorner: pt(1, -170e7), CrossesAntimeridian: true},
+			[]bounds{
+				{minLat: -1, minLon: 170e7, maxLat: 1, maxLon: maxLongitudeE7},
+				{minLat: -1, minLon: -maxLongitudeE7, maxLat: 1, maxLon: -170e7},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rectangleBounds(tt.rect)
+			if len(got) != len(tt.want) {
+				t.Fatalf("rectangleBounds(%v) = %v, want %v", tt.rect, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("rectangleBounds(%v) = %v, want %v", tt.rect, got, tt.want)
+				}
+			}
+		})
+	}
+}
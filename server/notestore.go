@@ -0,0 +1,175 @@
+package main
+
+import (
+	pb "routeguide/routeguide"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NoteStore keeps the route notes posted through RouteChat, grouped by
+// serialized location. Implementations are not safe for concurrent use;
+// routeGuideServer calls them with its mu held so that storing a note and
+// relaying it to subscribers happen as one step.
+type NoteStore interface {
+	// append assigns note the next sequence number and a creation time,
+	// then stores it at key
+	append(key string, note *pb.RouteNote) error
+	// history returns the notes stored at key with a sequence greater
+	// than after, oldest first. Callers must not modify the slice.
+	history(key string, after uint64) []*pb.RouteNote
+	// expire removes up to limit of the oldest notes created before cutoff
+	// and returns how many it removed
+	expire(cutoff time.Time, limit int) int
+	// close flushes anything buffered and releases the store's resources
+	close() error
+}
+
+// noteRef identifies a stored note in the arrival-order queue
+type noteRef struct {
+	key      string
+	sequence uint64
+}
+
+// memoryNoteStore is the in-memory NoteStore. It enforces the per-location
+// and global caps of its retention policy as notes are added.
+type memoryNoteStore struct {
+	routeNotes   map[string][]*pb.RouteNote // notes at each point; use serialized point as the key
+	lastSequence uint64                     // sequence number of the most recently stored note
+	noteOrder    []noteRef                  // stored notes in arrival order, oldest first, for global eviction
+	noteCount    int                        // number of notes currently in routeNotes
+	retention    retentionPolicy
+}
+
+func newMemoryNoteStore(retention retentionPolicy) *memoryNoteStore {
+	return &memoryNoteStore{
+		routeNotes: make(map[string][]*pb.RouteNote),
+		retention:  retention,
+	}
+}
+
+func (m *memoryNoteStore) append(key string, note *pb.RouteNote) error {
+	m.stamp(note)
+	m.insert(key, note)
+	return nil
+}
+
+// stamp sets the server-assigned fields of a note about to be stored
+func (m *memoryNoteStore) stamp(note *pb.RouteNote) {
+	note.Sequence = m.lastSequence + 1
+	note.CreatedAt = timestamppb.Now()
+	note.ResumeAfter = 0
+}
+
+// insert stores a note that already carries its sequence number and
+// creation time, as when replaying a log, and enforces the caps
+func (m *memoryNoteStore) insert(key string, note *pb.RouteNote) {
+	m.lastSequence = max(m.lastSequence, note.Sequence)
+	m.routeNotes[key] = append(m.routeNotes[key], note)
+	m.noteOrder = append(m.noteOrder, noteRef{key: key, sequence: note.Sequence})
+	m.noteCount++
+	notesStored.Add(1)
+
+	if limit := m.retention.maxPerLocation; limit > 0 {
+		for len(m.routeNotes[key]) > limit {
+			m.dropOldest(key)
+			notesEvicted.Add("location_cap", 1)
+		}
+	}
+	if limit := m.retention.maxTotal; limit > 0 {
+		for m.noteCount > limit && m.evictFront(func(*pb.RouteNote) bool { return true }) {
+			notesEvicted.Add("global_cap", 1)
+		}
+	}
+	m.compactNoteOrder()
+}
+
+func (m *memoryNoteStore) history(key string, after uint64) []*pb.RouteNote {
+	notes := m.routeNotes[key]
+	start := sort.Search(len(notes), func(i int) bool { return notes[i].Sequence > after })
+	return notes[start:]
+}
+
+func (m *memoryNoteStore) expire(cutoff time.Time, limit int) int {
+	expired := func(note *pb.RouteNote) bool { return note.CreatedAt.AsTime().Before(cutoff) }
+	n := 0
+	for n < limit && m.evictFront(expired) {
+		n++
+	}
+	return n
+}
+
+func (m *memoryNoteStore) close() error {
+	return nil
+}
+
+// all returns every stored note in arrival order
+func (m *memoryNoteStore) all() []*pb.RouteNote {
+	notes := make([]*pb.RouteNote, 0, m.noteCount)
+	for _, ref := range m.noteOrder {
+		if note := m.find(ref); note != nil {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// find returns the note ref points to, or nil if it has been removed
+func (m *memoryNoteStore) find(ref noteRef) *pb.RouteNote {
+	notes := m.routeNotes[ref.key]
+	i := sort.Search(len(notes), func(i int) bool { return notes[i].Sequence >= ref.sequence })
+	if i < len(notes) && notes[i].Sequence == ref.sequence {
+		return notes[i]
+	}
+	return nil
+}
+
+// dropOldest removes the first note stored at key
+func (m *memoryNoteStore) dropOldest(key string) {
+	notes := m.routeNotes[key]
+	notes[0] = nil
+	if len(notes) == 1 {
+		delete(m.routeNotes, key)
+	} else {
+		m.routeNotes[key] = notes[1:]
+	}
+	m.noteCount--
+	notesStored.Add(-1)
+}
+
+// evictFront removes the oldest stored note across all locations if evict
+// approves it, and reports whether a note was removed. Entries in noteOrder
+// whose note was already dropped by the per-location cap are skipped.
+func (m *memoryNoteStore) evictFront(evict func(*pb.RouteNote) bool) bool {
+	for len(m.noteOrder) > 0 {
+		ref := m.noteOrder[0]
+		notes := m.routeNotes[ref.key]
+		if len(notes) == 0 || notes[0].Sequence != ref.sequence {
+			m.noteOrder = m.noteOrder[1:]
+			continue
+		}
+		if !evict(notes[0]) {
+			return false
+		}
+		m.noteOrder = m.noteOrder[1:]
+		m.dropOldest(ref.key)
+		return true
+	}
+	return false
+}
+
+// compactNoteOrder drops stale noteOrder entries once they outnumber live
+// notes, so per-location evictions cannot make the queue grow without bound
+func (m *memoryNoteStore) compactNoteOrder() {
+	if len(m.noteOrder) <= 2*m.noteCount+janitorBatch {
+		return
+	}
+	live := make([]noteRef, 0, m.noteCount)
+	for _, ref := range m.noteOrder {
+		if m.find(ref) != nil {
+			live = append(live, ref)
+		}
+	}
+	m.noteOrder = live
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"cmp"
+	pb "routeguide/routeguide"
+	"slices"
+	"strings"
+)
+
+// sortKey is a feature's position in a ListFeatures result order. Only the
+// field for the requested order is set, plus the id, which breaks ties so
+// that every position is distinct and a page can resume right after one.
+type sortKey struct {
+	Name     string  `json:"s,omitempty"`
+	Distance float64 `json:"d,omitempty"`
+	Hilbert  uint64  `json:"h,omitempty"`
+	ID       string  `json:"i"`
+}
+
+func (k sortKey) compare(o sortKey) int {
+	return cmp.Or(
+		strings.Compare(k.Name, o.Name),
+		cmp.Compare(k.Distance, o.Distance),
+		cmp.Compare(k.Hilbert, o.Hilbert),
+		strings.Compare(k.ID, o.ID),
+	)
+}
+
+func idKey(feature *pb.Feature) sortKey   { return sortKey{ID: feature.Id} }
+func nameKey(feature *pb.Feature) sortKey { return sortKey{Name: feature.Name, ID: feature.Id} }
+
+// maxSortedHits is how many index hits past the resume position id and
+// name orders will collect and sort. Larger query areas walk the presorted
+// slice instead, which costs a binary search plus the features skipped for
+// lying outside the area, however many pages came before.
+const maxSortedHits = 4096
+
+// sortedBy returns a copy of features sorted by keyOf
+func sortedBy(features []*pb.Feature, keyOf func(*pb.Feature) sortKey) []*pb.Feature {
+	return slices.SortedFunc(slices.Values(features), func(a, b *pb.Feature) int {
+		return keyOf(a).compare(keyOf(b))
+	})
+}
+
+// ordered calls fn for the features inside boxes in the given order,
+// starting after the feature at position after (nil starts at the
+// beginning), until fn returns false. Name and id orders sort the index hits
+// of a small query area and otherwise walk a presorted slice from the resume
+// position, distance order runs a nearest-neighbour search that resumes at
+// the cursor's distance, and Hilbert order walks the R-tree from the
+// cursor's key. fn must still check that features match, since boxes may
+// over-cover the query area.
+func (fs *featureSet) ordered(order pb.SortOrder, ref *pb.Point, boxes []bounds, after *sortKey, fn func(*pb.Feature, sortKey) bool) {
+	// skip reports whether key is at or before the resume position
+	skip := func(key sortKey) bool { return after != nil && key.compare(*after) <= 0 }
+
+	switch order {
+	case pb.SortOrder_SORT_ORDER_NAME:
+		fs.sorted(fs.nameOrder, boxes, nameKey, after, fn)
+	case pb.SortOrder_SORT_ORDER_DISTANCE:
+		var from float64
+		if after != nil {
+			from = after.Distance
+		}
+		fs.index.nearest(ref, boxes, from, func(feature *pb.Feature, dist float64) bool {
+			key := sortKey{Distance: dist, ID: feature.Id}
+			return skip(key) || fn(feature, key)
+		})
+	case pb.SortOrder_SORT_ORDER_HILBERT:
+		var from uint64
+		if after != nil {
+			from = after.Hilbert
+		}
+		fs.index.hilbert(boxes, from, func(feature *pb.Feature, h uint64) bool {
+			key := sortKey{Hilbert: h, ID: feature.Id}
+			return skip(key) || fn(feature, key)
+		})
+	default:
+		fs.sorted(fs.idOrder, boxes, idKey, after, fn)
+	}
+}
+
+// sorted calls fn in keyOf order for the features inside boxes that come
+// after the given position. presorted holds every feature in keyOf order.
+// While the index finds at most maxSortedHits features to go, only those
+// are sorted; past that the query area covers enough of the catalogue that
+// walking presorted and dropping features outside boxes is cheaper.
+func (fs *featureSet) sorted(presorted []*pb.Feature, boxes []bounds, keyOf func(*pb.Feature) sortKey, after *sortKey, fn func(*pb.Feature, sortKey) bool) {
+	var hits []*pb.Feature
+	for _, b := range boxes {
+		fs.index.search(b, func(feature *pb.Feature) bool {
+			if after == nil || keyOf(feature).compare(*after) > 0 {
+				hits = append(hits, feature)
+			}
+			return len(hits) <= maxSortedHits
+		})
+		if len(hits) > maxSortedHits {
+			walkSorted(presorted, boxes, keyOf, after, fn)
+			return
+		}
+	}
+	slices.SortFunc(hits, func(a, b *pb.Feature) int { return keyOf(a).compare(keyOf(b)) })
+	// A feature on the edge two boxes share is found in both
+	hits = slices.Compact(hits)
+	for _, feature := range hits {
+		if !fn(feature, keyOf(feature)) {
+			return
+		}
+	}
+}
+
+// walkSorted calls fn for the features inside boxes of a slice sorted by
+// keyOf that come after the given position, finding the start by binary search
+func walkSorted(features []*pb.Feature, boxes []bounds, keyOf func(*pb.Feature) sortKey, after *sortKey, fn func(*pb.Feature, sortKey) bool) {
+	start := 0
+	if after != nil {
+		start, _ = slices.BinarySearchFunc(features, *after, func(feature *pb.Feature, key sortKey) int {
+			// Land on the first feature past the key, whether or not it still exists
+			if keyOf(feature).compare(key) <= 0 {
+				return -1
+			}
+			return 1
+		})
+	}
+	for _, feature := range features[start:] {
+		if inAny(boxes, feature.Location) && !fn(feature, keyOf(feature)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	pb "routeguide/routeguide"
+	"slices"
+	"testing"
+)
+
+// pageThrough collects every feature ordered returns inside boxes, a page
+// of pageSize at a time, resuming each page from the last key sent
+func pageThrough(fs *featureSet, order pb.SortOrder, boxes []bounds, pageSize int) []string {
+	var ids []string
+	var after *sortKey
+	for {
+		sent := 0
+		var last sortKey
+		fs.ordered(order, nil, boxes, after, func(feature *pb.Feature, key sortKey) bool {
+			if !anyContains(boxes, feature.Location) {
+				return true
+			}
+			ids = append(ids, feature.Id)
+			sent++
+			last = key
+			return sent < pageSize
+		})
+		// A page that fails to advance would otherwise repeat forever
+		if sent < pageSize || len(ids) > len(fs.features) {
+			return ids
+		}
+		after = &last
+	}
+}
+
+func TestOrderedPaging(t *testing.T) {
+	world := []bounds{{minLat: -maxLatitudeE7, minLon: -maxLongitudeE7, maxLat: maxLatitudeE7, maxLon: maxLongitudeE7}}
+	areas := map[string][]bounds{
+		"small":        {{minLat: 0, minLon: 0, maxLat: 20e7, maxLon: 20e7}},
+		"world":        world,
+		"antimeridian": rectangleBounds(&pb.Rectangle{BottomLeftCorner: pt(-60e7, 100e7), TopRightCorner: pt(60e7, -100e7), CrossesAntimeridian: true}),
+	}
+	orders := map[pb.SortOrder]func(*pb.Feature) sortKey{
+		pb.SortOrder_SORT_ORDER_ID:   idKey,
+		pb.SortOrder_SORT_ORDER_NAME: nameKey,
+	}
+	// Large enough for the world to exceed maxSortedHits and the small
+	// area to stay under it
+	for _, n := range []int{50, 4 * maxSortedHits} {
+		features := randomFeatures(n)
+		for i, feature := range features {
+			feature.Name = fmt.Sprint("Feature ", i%7) // repeated names are ordered by id
+		}
+		fs := newFeatureSet(features)
+		for areaName, boxes := range areas {
+			for order, keyOf := range orders {
+				var want []string
+				for _, feature := range sortedBy(features, keyOf) {
+					if anyContains(boxes, feature.Location) {
+						want = append(want, feature.Id)
+					}
+				}
+				for _, pageSize := range []int{1, 97, 100_000} {
+					if pageSize == 1 && n > 50 {
+						continue
+					}
+					t.Run(fmt.Sprintf("n=%d/%s/%v/page=%d", n, areaName, order, pageSize), func(t *testing.T) {
+						if got := pageThrough(fs, order, boxes, pageSize); !slices.Equal(got, want) {
+							t.Errorf("got %d features, want %d in order", len(got), len(want))
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkListFeaturesFirstPage times the first page of 100 features of a
+// world rectangle, in each order, over a large catalogue
+func BenchmarkListFeaturesFirstPage(b *testing.B) {
+	fs := newFeatureSet(randomFeatures(1_000_000))
+	world := []bounds{{minLat: -maxLatitudeE7, minLon: -maxLongitudeE7, maxLat: maxLatitudeE7, maxLon: maxLongitudeE7}}
+	for _, order := range []pb.SortOrder{
+		pb.SortOrder_SORT_ORDER_ID,
+		pb.SortOrder_SORT_ORDER_NAME,
+		pb.SortOrder_SORT_ORDER_DISTANCE,
+		pb.SortOrder_SORT_ORDER_HILBERT,
+	} {
+		b.Run(order.String(), func(b *testing.B) {
+			for b.Loop() {
+				sent := 0
+				fs.ordered(order, pt(0, 0), world, nil, func(*pb.Feature, sortKey) bool {
+					sent++
+					return sent < 100
+				})
+			}
+		})
+	}
+}
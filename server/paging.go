@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"hash/fnv"
+	pb "routeguide/routeguide"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// maxPageSize caps page_size; larger values are treated as this
+const maxPageSize = 1000
+
+// nextPageTokenKey is the trailer that carries the token for the next page
+const nextPageTokenKey = "next-page-token"
+
+// pageCursor is the decoded form of a page token. Pages are cut at the
+// sort key of the last feature sent rather than by position, so a token stays
+// meaningful when features are added, removed or reloaded between pages.
+type pageCursor struct {
+	After       *sortKey `json:"a"` // key of the last feature sent
+	Sent        int32    `json:"n"` // features sent on earlier pages, for max_results
+	Fingerprint uint64   `json:"f"` // requestFingerprint of the request the token belongs to
+}
+
+func (c pageCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageCursor parses a page token, returning ok=false if it is malformed.
+// The empty token is the start of the first page.
+func decodePageCursor(token string) (cursor pageCursor, ok bool) {
+	if token == "" {
+		return pageCursor{}, true
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageCursor{}, false
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return pageCursor{}, false
+	}
+	return cursor, true
+}
+
+// requestFingerprint hashes the fields of a ListFeatures request that must
+// stay the same from one page to the next
+func requestFingerprint(req *pb.ListFeaturesRequest) uint64 {
+	query := proto.Clone(req).(*pb.ListFeaturesRequest)
+	query.PageSize = 0
+	query.PageToken = ""
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(query)
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
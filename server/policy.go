@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	pb "routeguide/routeguide"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// accessPolicy decides which callers may invoke which methods. Methods are
+// named by their full gRPC name, such as /routeguide.RouteGuide/RouteChat;
+// a name ending in "/*" covers every method of a service and "*" covers
+// every method. For example:
+//
+//	{
+//	  "public": ["/routeguide.RouteGuide/GetFeature", "/routeguide.RouteGuide/ListFeatures"],
+//	  "roles": {
+//	    "writer": ["/routeguide.RouteGuide/RecordRoute", "/routeguide.RouteGuide/RouteChat"],
+//	    "admin": ["*"]
+//	  }
+//	}
+//
+// Public methods may be called by anyone, even without credentials. Any
+// other method needs an authenticated caller holding a role that lists it.
+type accessPolicy struct {
+	Public []string            `json:"public"`
+	Roles  map[string][]string `json:"roles"`
+}
+
+// loadPolicy reads a policy file. Every method it names must exist, so that
+// a typo fails at startup rather than silently denying or allowing calls.
+func loadPolicy(path string) (*accessPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p accessPolicy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	known := knownMethods()
+	check := func(where, pattern string) error {
+		for _, method := range known {
+			if methodMatches(pattern, method) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %s: %q matches no method", path, where, pattern)
+	}
+	for _, pattern := range p.Public {
+		if err := check("public", pattern); err != nil {
+			return nil, err
+		}
+	}
+	for _, role := range slices.Sorted(maps.Keys(p.Roles)) {
+		for _, pattern := range p.Roles[role] {
+			if err := check("roles."+role, pattern); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &p, nil
+}
+
+// knownMethods returns the full names of the methods the server serves
+func knownMethods() []string {
+	desc := pb.RouteGuide_ServiceDesc
+	var methods []string
+	for _, m := range desc.Methods {
+		methods = append(methods, "/"+desc.ServiceName+"/"+m.MethodName)
+	}
+	for _, s := range desc.Streams {
+		methods = append(methods, "/"+desc.ServiceName+"/"+s.StreamName)
+	}
+	return methods
+}
+
+func methodMatches(pattern, method string) bool {
+	if service, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(method, service+"/")
+	}
+	return pattern == "*" || pattern == method
+}
+
+// allows reports whether caller may invoke method; caller is nil for an
+// anonymous call
+func (p *accessPolicy) allows(method string, caller *identity) bool {
+	if slices.ContainsFunc(p.Public, func(pattern string) bool { return methodMatches(pattern, method) }) {
+		return true
+	}
+	if caller == nil {
+		return false
+	}
+	for _, role := range caller.roles {
+		if slices.ContainsFunc(p.Roles[role], func(pattern string) bool { return methodMatches(pattern, method) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize returns the error to fail a call with, or nil if it may proceed.
+// Every denial is logged for audit.
+func (p *accessPolicy) authorize(ctx context.Context, method string) error {
+	caller := identityFromContext(ctx)
+	if p.allows(method, caller) {
+		return nil
+	}
+
+	attrs := []any{"audit", true, "method", method}
+	if pr, ok := peer.FromContext(ctx); ok {
+		attrs = append(attrs, "peer", pr.Addr.String())
+	}
+	if caller == nil {
+		slog.Warn("denied anonymous call", attrs...)
+		return status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	attrs = append(attrs, "subject", caller.subject, "auth", caller.method, "roles", caller.roles)
+	slog.Warn("denied call", attrs...)
+	return status.Errorf(codes.PermissionDenied, "%s may not call %s", caller.subject, method)
+}
+
+func (p *accessPolicy) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := p.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (p *accessPolicy) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := p.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"path/filepath"
+	pb "routeguide/routeguide"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	getFeature    = "/routeguide.RouteGuide/GetFeature"
+	routeChat     = "/routeguide.RouteGuide/RouteChat"
+	createFeature = "/routeguide.RouteGuide/CreateFeature"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	p := &accessPolicy{
+		Public: []string{getFeature},
+		Roles: map[string][]string{
+			"writer": {routeChat},
+			"admin":  {"/routeguide.RouteGuide/*"},
+			"root":   {"*"},
+			"other":  {"/other.Service/*"},
+		},
+	}
+	caller := func(roles ...string) *identity { return &identity{subject: "alice", roles: roles} }
+	tests := []struct {
+		name   string
+		method string
+		caller *identity
+		want   bool
+	}{
+		{"public, anonymous", getFeature, nil, true},
+		{"public, no roles", getFeature, caller(), true},
+		{"private, anonymous", routeChat, nil, false},
+		{"private, no roles", routeChat, caller(), false},
+		{"listed method", routeChat, caller("writer"), true},
+		{"unlisted method", createFeature, caller("writer"), false},
+		{"unknown role", routeChat, caller("nobody"), false},
+		{"service wildcard", createFeature, caller("admin"), true},
+		{"service wildcard, other service", "/other.Service/Get", caller("admin"), false},
+		{"other service wildcard", createFeature, caller("other"), false},
+		{"global wildcard", createFeature, caller("root"), true},
+		{"global wildcard, other service", "/other.Service/Get", caller("root"), true},
+		{"any of several roles", createFeature, caller("writer", "admin"), true},
+		{"method name prefix", "/routeguide.RouteGuide/RouteChatter", caller("writer"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.allows(tt.method, tt.caller); got != tt.want {
+				t.Errorf("allows(%s, %v) = %v, want %v", tt.method, tt.caller, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMethodMatches(t *testing.T) {
+	tests := []struct {
+		pattern, method string
+		want            bool
+	}{
+		{"*", getFeature, true},
+		{"/routeguide.RouteGuide/*", getFeature, true},
+		{"/routeguide.Route/*", getFeature, false},
+		{"/routeguide.RouteGuide/*", "/routeguide.RouteGuideAdmin/Get", false},
+		{getFeature, getFeature, true},
+		{getFeature, routeChat, false},
+		{"/routeguide.RouteGuide/Get*", getFeature, false},
+	}
+	for _, tt := range tests {
+		if got := methodMatches(tt.pattern, tt.method); got != tt.want {
+			t.Errorf("methodMatches(%q, %q) = %v, want %v", tt.pattern, tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr string // empty if the policy is valid
+	}{
+		{"valid", `{"public": ["/routeguide.RouteGuide/GetFeature"], "roles": {"admin": ["/routeguide.RouteGuide/*"], "root": ["*"]}}`, ""},
+		{"empty", `{}`, ""},
+		{"unknown public method", `{"public": ["/routeguide.RouteGuide/GetFeatures"]}`, `public: "/routeguide.RouteGuide/GetFeatures" matches no method`},
+		{"unknown role method", `{"roles": {"writer": ["/routeguide.RouteGuide/RouteChat", "/routeguide.RouteGuide/Chat"]}}`, `roles.writer: "/routeguide.RouteGuide/Chat" matches no method`},
+		{"unknown service", `{"roles": {"admin": ["/routeguide.RouteGide/*"]}}`, "matches no method"},
+		{"method without service", `{"public": ["GetFeature"]}`, "matches no method"},
+		{"malformed", `{"public": "/routeguide.RouteGuide/GetFeature"}`, "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.json")
+			if err := os.WriteFile(path, []byte(tt.policy), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadPolicy(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("loadPolicy: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadPolicy error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyInterceptors(t *testing.T) {
+	// Capture the audit log
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	a := testAuthenticator()
+	a.anonymous = true
+	a.apiKeys[sha256.Sum256([]byte("reader-key"))] = &identity{subject: "carol", method: "api-key"}
+	p := &accessPolicy{
+		Public: []string{getFeature},
+		Roles:  map[string][]string{"writer": {"/routeguide.RouteGuide/*"}},
+	}
+	client := newTestClient(t,
+		grpc.ChainUnaryInterceptor(a.unaryInterceptor, p.unaryInterceptor),
+		grpc.ChainStreamInterceptor(a.streamInterceptor, p.streamInterceptor))
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), apiKeyKey, key)
+	}
+	deleteFeature := func(ctx context.Context) error {
+		_, err := client.DeleteFeature(ctx, &pb.DeleteFeatureRequest{Id: "no-such-feature"})
+		return err
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"public method, anonymous", func() error { _, err := client.GetFeature(context.Background(), pt(0, 0)); return err }, codes.OK},
+		{"public method, no role", func() error { _, err := client.GetFeature(withKey("reader-key"), pt(0, 0)); return err }, codes.OK},
+		{"private method, anonymous", func() error { return deleteFeature(context.Background()) }, codes.Unauthenticated},
+		{"private method, no role", func() error { return deleteFeature(withKey("reader-key")) }, codes.PermissionDenied},
+		{"private method, role", func() error { return deleteFeature(withKey("alice-key")) }, codes.NotFound},
+		{"stream, no role", func() error {
+			stream, err := client.RouteChat(withKey("reader-key"))
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			return err
+		}, codes.PermissionDenied},
+		{"unknown key on a public method", func() error { _, err := client.GetFeature(withKey("mallory-key"), pt(0, 0)); return err }, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, want := range []string{
+		`msg="denied call" audit=true method=/routeguide.RouteGuide/DeleteFeature`,
+		"subject=carol",
+		`msg="denied anonymous call" audit=true method=/routeguide.RouteGuide/DeleteFeature`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("audit log is missing %q:\n%s", want, logs.String())
+		}
+	}
+}
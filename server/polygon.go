@@ -0,0 +1,276 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	pb "routeguide/routeguide"
+	"slices"
+)
+
+// Limits on the size of a polygon, which bound the work of validating it:
+// every edge is checked for crossings against the edges whose bounding
+// boxes overlap its own
+const (
+	maxPolygonPoints = 10000 // across the outer ring and every hole
+	maxPolygonHoles  = 100
+)
+
+// polygon is a validated pb.Polygon with every ring opened (no repeated
+// closing point), ready for point-in-polygon tests
+type polygon struct {
+	exterior []*pb.Point
+	holes    [][]*pb.Point
+	bounds   bounds
+}
+
+// newPolygon validates p and returns its open-ring form, or a
+// codes.InvalidArgument status describing what is malformed. Coordinates are
+// treated as planar longitude/latitude; polygons are not wrapped across the
+// antimeridian.
+func newPolygon(p *pb.Polygon) (*polygon, error) {
+	var br badRequest
+	total := len(p.Points)
+	for _, hole := range p.Holes {
+		total += len(hole.GetPoints())
+	}
+	if total > maxPolygonPoints {
+		br.add("points", "polygon has %d points across its rings, limit is %d", total, maxPolygonPoints)
+	}
+	if len(p.Holes) > maxPolygonHoles {
+		br.add("holes", "polygon has %d holes, limit is %d", len(p.Holes), maxPolygonHoles)
+	}
+	if !br.ok() {
+		return nil, br.err()
+	}
+
+	exterior := openRing(&br, "points", p.Points)
+	if exterior == nil {
+		return nil, br.err()
+	}
+	poly := &polygon{exterior: exterior, bounds: ringBounds(exterior)}
+
+	// rings[0] is the exterior and every other ring is a hole; fields names
+	// each ring in violations
+	rings := [][]*pb.Point{exterior}
+	fields := []string{"points"}
+	for i, hole := range p.Holes {
+		field := fmt.Sprintf("holes[%d].points", i)
+		if ring := openRing(&br, field, hole.GetPoints()); ring != nil {
+			rings = append(rings, ring)
+			fields = append(fields, field)
+		}
+	}
+	if !br.ok() {
+		return nil, br.err()
+	}
+
+	if c, ok := firstCrossing(rings); ok {
+		edge := func(ring, i int) string { return fmt.Sprintf("%d-%d", i, (i+1)%len(rings[ring])) }
+		switch {
+		case c.ringA == c.ringB:
+			br.add(fields[c.ringA], "edge %s crosses edge %s", edge(c.ringA, c.edgeA), edge(c.ringB, c.edgeB))
+		case c.ringA == 0:
+			br.add(fields[c.ringB], "hole edge %s touches polygon edge %s", edge(c.ringB, c.edgeB), edge(c.ringA, c.edgeA))
+		default:
+			br.add(fields[c.ringB], "hole edge %s touches edge %s of %s", edge(c.ringB, c.edgeB), edge(c.ringA, c.edgeA), fields[c.ringA])
+		}
+		return nil, br.err()
+	}
+
+	// With no edges touching, each ring lies wholly inside or wholly outside
+	// every other, so one point of a ring settles which
+	for k := 1; k < len(rings); k++ {
+		ring := rings[k]
+		if inside, _ := ringContains(exterior, ring[0]); !inside {
+			br.add(fields[k]+"[0]", "hole lies outside the polygon")
+			continue
+		}
+		for j := 1; j < k; j++ {
+			if inside, _ := ringContains(rings[j], ring[0]); inside {
+				br.add(fields[k], "hole lies inside %s", fields[j])
+				break
+			}
+			if inside, _ := ringContains(ring, rings[j][0]); inside {
+				br.add(fields[k], "hole encloses %s", fields[j])
+				break
+			}
+		}
+		poly.holes = append(poly.holes, ring)
+	}
+	if !br.ok() {
+		return nil, br.err()
+	}
+	return poly, nil
+}
+
+// contains reports whether point lies in the polygon. Points on the outer
+// ring or on a hole's ring count as inside.
+func (poly *polygon) contains(point *pb.Point) bool {
+	if !poly.bounds.contains(point) {
+		return false
+	}
+	if inside, _ := ringContains(poly.exterior, point); !inside {
+		return false
+	}
+	for _, hole := range poly.holes {
+		if inside, onEdge := ringContains(hole, point); inside && !onEdge {
+			return false
+		}
+	}
+	return true
+}
+
+// openRing checks the points of a ring and drops the closing point if the
+// caller repeated the first one. It returns nil after recording violations
+// under field if the ring is malformed. Crossing edges are left to
+// firstCrossing, which checks every ring of the polygon at once.
+func openRing(br *badRequest, field string, points []*pb.Point) []*pb.Point {
+	valid := true
+	for i, point := range points {
+		before := len(br.violations)
+		br.checkPoint(fmt.Sprintf("%s[%d]", field, i), point)
+		valid = valid && len(br.violations) == before
+	}
+	if !valid {
+		return nil
+	}
+	if n := len(points); n > 1 && samePoint(points[0], points[n-1]) {
+		points = points[:n-1]
+	}
+	if len(points) < 3 {
+		br.add(field, "ring needs at least 3 distinct points, got %d", len(points))
+		return nil
+	}
+
+	n := len(points)
+	collinear := true
+	for i := range points {
+		if samePoint(points[i], points[(i+1)%n]) {
+			br.add(fmt.Sprintf("%s[%d]", field, i), "repeats the previous point (%d, %d)", points[i].Latitude, points[i].Longitude)
+			return nil
+		}
+		if orientation(points[0], points[1], points[i]) != 0 {
+			collinear = false
+		}
+	}
+	if collinear {
+		br.add(field, "ring has zero area")
+		return nil
+	}
+	return points
+}
+
+// crossing names two edges that touch, each by the index of its ring and
+// of its first point in the ring, with ring A before ring B
+type crossing struct {
+	ringA, edgeA int
+	ringB, edgeB int
+}
+
+// firstCrossing finds two edges of rings that touch, apart from neighbours
+// in a ring, which always share a point. It sweeps the edges from west to
+// east, testing each only against the edges still open at its western end
+// whose latitude ranges overlap its own.
+func firstCrossing(rings [][]*pb.Point) (crossing, bool) {
+	type edge struct {
+		ring, index int
+		a, b        *pb.Point
+		box         bounds
+	}
+	var edges []edge
+	for r, ring := range rings {
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			edges = append(edges, edge{ring: r, index: i, a: a, b: b, box: pointBounds(a).union(pointBounds(b))})
+		}
+	}
+	slices.SortFunc(edges, func(x, y edge) int { return cmp.Compare(x.box.minLon, y.box.minLon) })
+
+	neighbours := func(x, y edge) bool {
+		n := len(rings[x.ring])
+		return x.ring == y.ring && ((x.index+1)%n == y.index || (y.index+1)%n == x.index)
+	}
+	var open []edge
+	for _, e := range edges {
+		open = slices.DeleteFunc(open, func(o edge) bool { return o.box.maxLon < e.box.minLon })
+		for _, o := range open {
+			if o.box.maxLat < e.box.minLat || e.box.maxLat < o.box.minLat || neighbours(o, e) {
+				continue
+			}
+			if segmentsIntersect(o.a, o.b, e.a, e.b) {
+				if e.ring < o.ring || e.ring == o.ring && e.index < o.index {
+					o, e = e, o
+				}
+				return crossing{ringA: o.ring, edgeA: o.index, ringB: e.ring, edgeB: e.index}, true
+			}
+		}
+		open = append(open, e)
+	}
+	return crossing{}, false
+}
+
+// ringBounds returns the box enclosing every point of ring
+func ringBounds(ring []*pb.Point) bounds {
+	b := pointBounds(ring[0])
+	for _, point := range ring[1:] {
+		b = b.union(pointBounds(point))
+	}
+	return b
+}
+
+// ringContains reports whether p is inside or on the open ring, and
+// separately whether it lies exactly on an edge
+func ringContains(ring []*pb.Point, p *pb.Point) (inside, onEdge bool) {
+	n := len(ring)
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%n]
+		if orientation(a, b, p) == 0 && onSegment(a, b, p) {
+			return true, true
+		}
+		// Crossing-number test against a ray heading east from p
+		switch {
+		case a.Latitude <= p.Latitude && b.Latitude > p.Latitude:
+			if orientation(a, b, p) > 0 {
+				inside = !inside
+			}
+		case b.Latitude <= p.Latitude && a.Latitude > p.Latitude:
+			if orientation(a, b, p) < 0 {
+				inside = !inside
+			}
+		}
+	}
+	return inside, false
+}
+
+// orientation is positive when p lies to the left of the directed line a→b
+// (longitude as x, latitude as y), negative to the right and zero when the
+// three points are collinear. It compares the two halves of the cross
+// product rather than subtracting them, as each half fits in an int64 but
+// their difference may not.
+func orientation(a, b, p *pb.Point) int {
+	lhs := (int64(b.Longitude) - int64(a.Longitude)) * (int64(p.Latitude) - int64(a.Latitude))
+	rhs := (int64(b.Latitude) - int64(a.Latitude)) * (int64(p.Longitude) - int64(a.Longitude))
+	return cmp.Compare(lhs, rhs)
+}
+
+// onSegment reports whether p, already known to be collinear with a and b,
+// falls between them
+func onSegment(a, b, p *pb.Point) bool {
+	return p.Longitude >= min(a.Longitude, b.Longitude) && p.Longitude <= max(a.Longitude, b.Longitude) &&
+		p.Latitude >= min(a.Latitude, b.Latitude) && p.Latitude <= max(a.Latitude, b.Latitude)
+}
+
+// segmentsIntersect reports whether segments p1-p2 and q1-q2 share any point
+func segmentsIntersect(p1, p2, q1, q2 *pb.Point) bool {
+	o1, o2 := orientation(p1, p2, q1), orientation(p1, p2, q2)
+	o3, o4 := orientation(q1, q2, p1), orientation(q1, q2, p2)
+	if o1*o2 < 0 && o3*o4 < 0 {
+		return true
+	}
+	return (o1 == 0 && onSegment(p1, p2, q1)) || (o2 == 0 && onSegment(p1, p2, q2)) ||
+		(o3 == 0 && onSegment(q1, q2, p1)) || (o4 == 0 && onSegment(q1, q2, p2))
+}
+
+func samePoint(a, b *pb.Point) bool {
+	return a.Latitude == b.Latitude && a.Longitude == b.Longitude
+}
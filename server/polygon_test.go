@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	pb "routeguide/routeguide"
+	"slices"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func ring(points ...*pb.Point) *pb.LinearRing {
+	return &pb.LinearRing{Points: points}
+}
+
+// square returns the ring of the axis-aligned square with corners (lo, lo) and (hi, hi)
+func square(lo, hi int32) *pb.LinearRing {
+	return ring(pt(lo, lo), pt(lo, hi), pt(hi, hi), pt(hi, lo))
+}
+
+func TestNewPolygonHoles(t *testing.T) {
+	exterior := square(0, 100).Points
+	tests := []struct {
+		name  string
+		holes []*pb.LinearRing
+		field string // expected BadRequest field, or empty if the polygon is valid
+	}{
+		{"hole inside", []*pb.LinearRing{square(10, 20)}, ""},
+		{"two separate holes", []*pb.LinearRing{square(10, 20), square(30, 40)}, ""},
+		{"hole crossing the exterior", []*pb.LinearRing{square(90, 110)}, "holes[0].points"},
+		{"hole on an exterior edge", []*pb.LinearRing{ring(pt(0, 10), pt(10, 20), pt(10, 10))}, "holes[0].points"},
+		{"hole sharing an exterior vertex", []*pb.LinearRing{ring(pt(0, 0), pt(10, 20), pt(20, 10))}, "holes[0].points"},
+		{"hole outside", []*pb.LinearRing{square(200, 300)}, "holes[0].points[0]"},
+		{"hole enclosing the polygon", []*pb.LinearRing{square(-10, 110)}, "holes[0].points[0]"},
+		{"overlapping holes", []*pb.LinearRing{square(10, 30), square(20, 40)}, "holes[1].points"},
+		{"holes sharing a vertex", []*pb.LinearRing{square(10, 20), square(20, 30)}, "holes[1].points"},
+		{"hole inside a hole", []*pb.LinearRing{square(10, 50), square(20, 30)}, "holes[1].points"},
+		{"hole enclosing a hole", []*pb.LinearRing{square(20, 30), square(10, 50)}, "holes[1].points"},
+		{"later hole overlaps the first", []*pb.LinearRing{square(10, 20), square(60, 70), square(15, 25)}, "holes[2].points"},
+		{"self-crossing hole", []*pb.LinearRing{ring(pt(10, 10), pt(20, 20), pt(10, 20), pt(20, 10))}, "holes[0].points"},
+		{"too many holes", slices.Repeat([]*pb.LinearRing{square(10, 20)}, maxPolygonHoles+1), "holes"},
+		{"too many points", []*pb.LinearRing{ring(slices.Repeat([]*pb.Point{pt(1, 1)}, maxPolygonPoints)...)}, "points"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPolygon(&pb.Polygon{Points: exterior, Holes: tt.holes})
+			checkViolation(t, err, tt.field)
+		})
+	}
+}
+
+func TestNewPolygonExterior(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []*pb.Point
+		field  string
+	}{
+		{"square", square(0, 100).Points, ""},
+		{"closed square", append(square(0, 100).Points, pt(0, 0)), ""},
+		{"bow tie", []*pb.Point{pt(0, 0), pt(10, 10), pt(0, 10), pt(10, 0)}, "points"},
+		{"edge touching a vertex", []*pb.Point{pt(0, 0), pt(0, 10), pt(5, 5), pt(10, 10), pt(10, 0), pt(5, 5)}, "points"},
+		{"repeated point", []*pb.Point{pt(0, 0), pt(0, 10), pt(0, 10), pt(10, 10)}, "points[1]"},
+		{"collinear", []*pb.Point{pt(0, 0), pt(0, 5), pt(0, 10)}, "points"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPolygon(&pb.Polygon{Points: tt.points})
+			checkViolation(t, err, tt.field)
+		})
+	}
+}
+
+// checkViolation fails unless err is nil when field is empty, or else an
+// InvalidArgument status whose BadRequest detail names only field
+func checkViolation(t *testing.T, err error, field string) {
+	t.Helper()
+	if field == "" {
+		if err != nil {
+			t.Fatalf("newPolygon: %v", err)
+		}
+		return
+	}
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("newPolygon: %v, want InvalidArgument", err)
+	}
+	var fields []string
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	if len(fields) != 1 || fields[0] != field {
+		t.Errorf("violations on %v, want only %s (%v)", fields, field, err)
+	}
+}
+
+// circle returns a ring of n points around (lat, lon) at radius r
+func circle(lat, lon, r float64, n int) *pb.LinearRing {
+	points := make([]*pb.Point, n)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = pt(int32(lat+r*math.Sin(angle)), int32(lon+r*math.Cos(angle)))
+	}
+	return ring(points...)
+}
+
+// BenchmarkNewPolygon validates the largest polygons a client may send: a
+// ring of every allowed point, and a ring sharing them with the most holes
+func BenchmarkNewPolygon(b *testing.B) {
+	var holes []*pb.LinearRing
+	for i := range maxPolygonHoles {
+		holes = append(holes, circle(float64(i/10-5)*1e6, float64(i%10-5)*1e6, 3e5, 20))
+	}
+	polygons := map[string]*pb.Polygon{
+		"one ring":   {Points: circle(0, 0, 1e8, maxPolygonPoints).Points},
+		"with holes": {Points: circle(0, 0, 1e8, maxPolygonPoints-20*maxPolygonHoles).Points, Holes: holes},
+	}
+	for name, p := range polygons {
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := newPolygon(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
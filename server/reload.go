@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// watchFeatures reloads the feature catalogue from path whenever the process
+// receives SIGHUP or, if interval is positive, when the file's size or
+// modification time changes. A file that fails to load is logged and the
+// previous catalogue keeps being served.
+func (s *routeGuideServer) watchFeatures(path string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last, _ := os.Stat(path)
+	for {
+		select {
+		case <-hup:
+			slog.Info("SIGHUP received, reloading features", "path", path)
+		case <-tick:
+			info, err := os.Stat(path)
+			if err != nil || !fileChanged(last, info) {
+				continue
+			}
+			last = info
+			slog.Info("features file changed, reloading", "path", path)
+		}
+		s.reloadFeatures(path)
+	}
+}
+
+// reloadFeatures loads path and swaps it in as the served catalogue.
+// In-flight calls finish against the snapshot they started with.
+func (s *routeGuideServer) reloadFeatures(path string) {
+	features, err := loadFeatures(path)
+	if err != nil {
+		slog.Error("reload failed, keeping current features", "err", err)
+		return
+	}
+	s.setFeatures(features)
+	slog.Info("reloaded features", "count", len(features), "path", path)
+}
+
+func fileChanged(old, cur os.FileInfo) bool {
+	if old == nil {
+		return true
+	}
+	return !cur.ModTime().Equal(old.ModTime()) || cur.Size() != old.Size()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"expvar"
+	"log/slog"
+	"time"
+)
+
+// janitorBatch is the most notes the janitor expires per acquisition of
+// s.mu, so RouteChat streams never wait behind a large cleanup
+const janitorBatch = 1000
+
+// Eviction counters, published at /debug/vars when -debug-addr is set
+var (
+	notesEvicted = expvar.NewMap("routeguide_notes_evicted") // by reason: location_cap, global_cap, expired
+	notesStored  = expvar.NewInt("routeguide_notes_stored")
+)
+
+// retentionPolicy bounds how many route notes the server keeps in memory.
+// A zero value in any field means no limit of that kind.
+type retentionPolicy struct {
+	maxPerLocation int           // notes kept at any one location; older ones are dropped first
+	maxTotal       int           // notes kept across all locations
+	maxAge         time.Duration // notes older than this are expired by the janitor
+}
+
+// expireNotes removes notes created before cutoff, a batch at a time,
+// and returns how many it removed
+func (s *routeGuideServer) expireNotes(cutoff time.Time) int {
+	total := 0
+	for {
+		s.mu.Lock()
+		n := s.notes.expire(cutoff, janitorBatch)
+		s.mu.Unlock()
+
+		total += n
+		if n < janitorBatch {
+			if total > 0 {
+				notesEvicted.Add("expired", int64(total))
+			}
+			return total
+		}
+	}
+}
+
+// runJanitor expires notes older than maxAge every interval
+func (s *routeGuideServer) runJanitor(maxAge, interval time.Duration) {
+	if maxAge <= 0 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if n := s.expireNotes(time.Now().Add(-maxAge)); n > 0 {
+			slog.Info("expired route notes", "count", n, "max_age", maxAge)
+		}
+	}
+}
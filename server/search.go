@@ -0,0 +1,144 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	pb "routeguide/routeguide"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Search limits
+const (
+	defaultSearchResults = 10
+	maxSearchResults     = 100
+	maxQueryLength       = 256
+)
+
+// Relevance weights. A word in the name counts for more than one in the
+// description, and a query word that only prefixes an indexed word scores
+// less than an exact match.
+const (
+	nameWeight        = 3
+	descriptionWeight = 1
+	prefixPenalty     = 0.5
+)
+
+// textIndex is an inverted index over feature names and descriptions. Like
+// the spatial index it is built once per featureSet and never modified, so
+// reloads and the admin RPCs keep it in sync by replacing it.
+type textIndex struct {
+	terms    []string             // every indexed word, sorted, for prefix lookups
+	postings map[string][]posting // features containing each word
+	size     int                  // number of indexed features
+}
+
+// posting records that features[feature] contains a word, weighted by
+// where and how often it occurs
+type posting struct {
+	feature int
+	weight  float64
+}
+
+func newTextIndex(features []*pb.Feature) *textIndex {
+	idx := &textIndex{postings: make(map[string][]posting), size: len(features)}
+	for i, feature := range features {
+		weights := make(map[string]float64)
+		for _, term := range tokenize(feature.Name) {
+			weights[term] += nameWeight
+		}
+		for _, term := range tokenize(feature.Description) {
+			weights[term] += descriptionWeight
+		}
+		for term, weight := range weights {
+			idx.postings[term] = append(idx.postings[term], posting{feature: i, weight: weight})
+		}
+	}
+	idx.terms = make([]string, 0, len(idx.postings))
+	for term := range idx.postings {
+		idx.terms = append(idx.terms, term)
+	}
+	sort.Strings(idx.terms)
+	return idx
+}
+
+// tokenize splits text into lower-case words of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// search scores every feature that matches all of the query words and
+// returns their positions in the feature list with scores, best first
+func (idx *textIndex) search(words []string) map[int]float64 {
+	var scores map[int]float64
+	for _, word := range words {
+		// A feature's score for a word is its best-scoring matching term
+		best := make(map[int]float64)
+		start := sort.SearchStrings(idx.terms, word)
+		for _, term := range idx.terms[start:] {
+			if !strings.HasPrefix(term, word) {
+				break
+			}
+			postings := idx.postings[term]
+			weight := math.Log(1 + float64(idx.size)/float64(len(postings)))
+			if term != word {
+				weight *= prefixPenalty
+			}
+			for _, p := range postings {
+				best[p.feature] = max(best[p.feature], p.weight*weight)
+			}
+		}
+
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for feature, score := range scores {
+			if s, ok := best[feature]; ok {
+				scores[feature] = score + s
+			} else {
+				delete(scores, feature)
+			}
+		}
+	}
+	return scores
+}
+
+// SearchFeatures streams the features matching every word of the query,
+// highest score first; ties are broken by name
+func (s *routeGuideServer) SearchFeatures(req *pb.SearchRequest, stream pb.RouteGuide_SearchFeaturesServer) error {
+	words, err := validateSearchRequest(req)
+	if err != nil {
+		return err
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultSearchResults
+	}
+
+	fs := s.snapshot()
+	var results []*pb.SearchResult
+	for i, score := range fs.text.search(words) {
+		results = append(results, &pb.SearchResult{Feature: fs.features[i], Score: score})
+	}
+	// Equal scores fall back to name and then id, so features that share a
+	// name come back in the same order on every call
+	slices.SortFunc(results, func(a, b *pb.SearchResult) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(a.Feature.Name, b.Feature.Name),
+			cmp.Compare(a.Feature.Id, b.Feature.Id),
+		)
+	})
+
+	for _, result := range results[:min(limit, len(results))] {
+		if err := stream.Send(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
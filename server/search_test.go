@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"io"
+	pb "routeguide/routeguide"
+	"slices"
+	"testing"
+)
+
+// Features with the same name and the same score come back in id order,
+// whatever their order in the catalogue
+func TestSearchFeaturesTieBreak(t *testing.T) {
+	var features []*pb.Feature
+	for i, id := range []string{"c", "a", "d", "b"} {
+		features = append(features, &pb.Feature{Id: id, Name: "Lookout", Location: pt(int32(i), 0)})
+	}
+	features = append(features, &pb.Feature{Id: "e", Name: "Lookout Tower", Location: pt(10, 0)})
+	client := serveTestClient(t, newServer(features, newMemoryNoteStore(retentionPolicy{})))
+
+	stream, err := client.SearchFeatures(context.Background(), &pb.SearchRequest{Query: "lookout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Feature.Name == "Lookout" {
+			ids = append(ids, result.Feature.Id)
+		}
+	}
+	if want := []string{"a", "b", "c", "d"}; !slices.Equal(ids, want) {
+		t.Errorf("equally scored features came back as %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval is how often handshakes look for a renewed certificate
+const certCheckInterval = 5 * time.Second
+
+// certReloader serves a key pair from disk and picks up a renewed certificate
+// without a restart: at most once per certCheckInterval a handshake checks
+// whether either file changed and, if so, loads the new pair. A pair that
+// fails to load is logged and the previous one keeps being served.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu          sync.Mutex
+	cert        *tls.Certificate
+	certInfo    os.FileInfo // state of the files the current pair was loaded from
+	keyInfo     os.FileInfo
+	lastChecked time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads the key pair; r.mu must be held or r not yet shared
+func (r *certReloader) load() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.certInfo, r.keyInfo = &cert, certInfo, keyInfo
+	r.lastChecked = time.Now()
+	return nil
+}
+
+// getCertificate is the tls.Config.GetCertificate callback
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.lastChecked) < certCheckInterval {
+		return r.cert, nil
+	}
+	r.lastChecked = time.Now()
+
+	certInfo, certErr := os.Stat(r.certFile)
+	keyInfo, keyErr := os.Stat(r.keyFile)
+	if certErr != nil || keyErr != nil || (!fileChanged(r.certInfo, certInfo) && !fileChanged(r.keyInfo, keyInfo)) {
+		return r.cert, nil
+	}
+	if err := r.load(); err != nil {
+		slog.Error("TLS certificate reload failed, keeping the current certificate", "err", err)
+		return r.cert, nil
+	}
+	slog.Info("reloaded TLS certificate", "cert", r.certFile)
+	return r.cert, nil
+}
+
+// serverTLSConfig builds the server's TLS configuration from the tls-*
+// settings. With a client CA, clients must present a certificate it signed.
+func (c *config) serverTLSConfig() (*tls.Config, error) {
+	reloader, err := newCertReloader(c.tlsCert, c.tlsKey)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS key pair: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+	if c.tlsClientCA != "" {
+		pool, err := loadCertPool(c.tlsClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("loading client CA: %w", err)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// loadCertPool reads a PEM file of one or more CA certificates
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a throwaway certificate authority
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue signs a certificate for localhost with the given serial number and
+// usage, and returns its certificate and key in PEM
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testTLSConfig writes a CA and a server key pair signed by it to a temp
+// directory and returns the CA and a config naming the files
+func testTLSConfig(t *testing.T) (*testCA, *config) {
+	t.Helper()
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	c := &config{
+		tlsCert: filepath.Join(dir, "server.pem"),
+		tlsKey:  filepath.Join(dir, "server.key"),
+	}
+	writeFile(t, c.tlsCert, certPEM)
+	writeFile(t, c.tlsKey, keyPEM)
+	writeFile(t, filepath.Join(dir, "ca.pem"), ca.pem)
+	return ca, c
+}
+
+// handshake runs a TLS handshake between the two configurations over a
+// loopback connection and returns the error each side saw
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (serverErr, clientErr error) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		serverConn, err := lis.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		serverConn.SetDeadline(time.Now().Add(10 * time.Second))
+		server := tls.Server(serverConn, serverConfig)
+		err = server.Handshake()
+		if err == nil {
+			// With TLS 1.3 the server only sees a missing client
+			// certificate once it reads from the connection
+			_, err = server.Read(make([]byte, 1))
+		}
+		serverConn.Close()
+		done <- err
+	}()
+	clientConn, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConn.SetDeadline(time.Now().Add(10 * time.Second))
+	client := tls.Client(clientConn, clientConfig)
+	clientErr = client.Handshake()
+	if clientErr == nil {
+		_, clientErr = client.Write([]byte{1})
+	}
+	clientConn.Close()
+	return <-done, clientErr
+}
+
+func clientConfig(t *testing.T, ca *testCA) *tls.Config {
+	t.Helper()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return &tls.Config{RootCAs: roots, ServerName: "localhost"}
+}
+
+func TestServerTLSHandshake(t *testing.T) {
+	ca, c := testTLSConfig(t)
+	serverConfig, err := c.serverTLSConfig()
+	if err != nil {
+		t.Fatalf("serverTLSConfig: %v", err)
+	}
+	serverErr, clientErr := handshake(t, serverConfig, clientConfig(t, ca))
+	if serverErr != nil || clientErr != nil {
+		t.Fatalf("handshake failed: server %v, client %v", serverErr, clientErr)
+	}
+
+	// A client that does not trust the CA must refuse the server
+	_, clientErr = handshake(t, serverConfig, &tls.Config{ServerName: "localhost", RootCAs: x509.NewCertPool()})
+	if clientErr == nil {
+		t.Error("client accepted a server certificate from an untrusted CA")
+	}
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	ca, c := testTLSConfig(t)
+	c.tlsClientCA = filepath.Join(filepath.Dir(c.tlsCert), "ca.pem")
+	serverConfig, err := c.serverTLSConfig()
+	if err != nil {
+		t.Fatalf("serverTLSConfig: %v", err)
+	}
+
+	if serverErr, _ := handshake(t, serverConfig, clientConfig(t, ca)); serverErr == nil {
+		t.Error("server accepted a client without a certificate")
+	}
+
+	certPEM, keyPEM := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withCert := clientConfig(t, ca)
+	withCert.Certificates = []tls.Certificate{clientCert}
+	if serverErr, clientErr := handshake(t, serverConfig, withCert); serverErr != nil || clientErr != nil {
+		t.Errorf("handshake with a client certificate failed: server %v, client %v", serverErr, clientErr)
+	}
+
+	// A certificate from another CA is no better than none
+	otherPEM, otherKey := newTestCA(t).issue(t, 4, x509.ExtKeyUsageClientAuth)
+	otherCert, err := tls.X509KeyPair(otherPEM, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withOther := clientConfig(t, ca)
+	withOther.Certificates = []tls.Certificate{otherCert}
+	if serverErr, _ := handshake(t, serverConfig, withOther); serverErr == nil {
+		t.Error("server accepted a client certificate from an untrusted CA")
+	}
+}
+
+func TestCertReloaderPicksUpRenewedPair(t *testing.T) {
+	ca, c := testTLSConfig(t)
+	r, err := newCertReloader(c.tlsCert, c.tlsKey)
+	if err != nil {
+		t.Fatalf("newCertReloader: %v", err)
+	}
+	serial := func() int64 {
+		t.Helper()
+		cert, err := r.getCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return leaf.SerialNumber.Int64()
+	}
+	if got := serial(); got != 2 {
+		t.Fatalf("serving serial %d, want 2", got)
+	}
+
+	// Renew the pair; a later modification time guarantees the change is
+	// seen even on file systems with coarse timestamps
+	certPEM, keyPEM := ca.issue(t, 5, x509.ExtKeyUsageServerAuth)
+	writeFile(t, c.tlsCert, certPEM)
+	writeFile(t, c.tlsKey, keyPEM)
+	later := time.Now().Add(time.Minute)
+	for _, path := range []string{c.tlsCert, c.tlsKey} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := serial(); got != 2 {
+		t.Errorf("serving serial %d within certCheckInterval of the last check, want the old 2", got)
+	}
+
+	// Pretend certCheckInterval has passed
+	r.mu.Lock()
+	r.lastChecked = time.Now().Add(-certCheckInterval)
+	r.mu.Unlock()
+	if got := serial(); got != 5 {
+		t.Errorf("serving serial %d after the pair was renewed, want 5", got)
+	}
+
+	// A broken pair is ignored and the current one kept
+	writeFile(t, c.tlsKey, []byte("not a key"))
+	if err := os.Chtimes(c.tlsKey, later.Add(time.Minute), later.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	r.mu.Lock()
+	r.lastChecked = time.Now().Add(-certCheckInterval)
+	r.mu.Unlock()
+	if got := serial(); got != 5 {
+		t.Errorf("serving serial %d after a failed reload, want 5", got)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	pb "routeguide/routeguide"
+	"slices"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxNearestK caps how many features a single NearestFeatures call can ask for
+const maxNearestK = 1000
+
+// badRequest collects field violations for a request message. Its err method
+// turns them into a codes.InvalidArgument status carrying a google.rpc.BadRequest
+// detail, so clients can tell exactly which fields were wrong.
+type badRequest struct {
+	violations []*errdetails.BadRequest_FieldViolation
+}
+
+func (br *badRequest) add(field, format string, args ...any) {
+	br.violations = append(br.violations, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+func (br *badRequest) ok() bool {
+	return len(br.violations) == 0
+}
+
+// err returns nil if no violations were recorded
+func (br *badRequest) err() error {
+	if br.ok() {
+		return nil
+	}
+	first := br.violations[0]
+	msg := fmt.Sprintf("invalid %s: %s", first.Field, first.Description)
+	if n := len(br.violations); n > 1 {
+		msg += fmt.Sprintf(" (and %d more)", n-1)
+	}
+	st, err := status.New(codes.InvalidArgument, msg).WithDetails(&errdetails.BadRequest{FieldViolations: br.violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return st.Err()
+}
+
+// checkPoint records violations for a missing point or out-of-range coordinates.
+// field is the point's path in the request, or "" when the point is the request.
+func (br *badRequest) checkPoint(field string, p *pb.Point) {
+	if p == nil {
+		br.add(field, "is required")
+		return
+	}
+	if p.Latitude < -maxLatitudeE7 || p.Latitude > maxLatitudeE7 {
+		br.add(fieldPath(field, "latitude"), "must be between %d and %d, got %d", -maxLatitudeE7, maxLatitudeE7, p.Latitude)
+	}
+	if p.Longitude < -maxLongitudeE7 || p.Longitude > maxLongitudeE7 {
+		br.add(fieldPath(field, "longitude"), "must be between %d and %d, got %d", -maxLongitudeE7, maxLongitudeE7, p.Longitude)
+	}
+}
+
+// checkDistance records a violation unless d is a finite, non-negative number of metres
+func (br *badRequest) checkDistance(field string, d float64) {
+	if math.IsNaN(d) || math.IsInf(d, 0) || d < 0 {
+		br.add(field, "must be a non-negative number of metres, got %v", d)
+	}
+}
+
+func fieldPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
+
+func validatePoint(p *pb.Point) error {
+	var br badRequest
+	br.checkPoint("", p)
+	return br.err()
+}
+
+// validateRoutePoint checks the i-th point of a RecordRoute stream
+func validateRoutePoint(i int32, p *pb.Point) error {
+	var br badRequest
+	br.checkPoint(fmt.Sprintf("points[%d]", i), p)
+	return br.err()
+}
+
+// validateListFeaturesRequest checks the rectangle, filter, ordering and paging fields
+// and returns the compiled filter, which is nil if the request has none,
+// and where the page starts
+func validateListFeaturesRequest(req *pb.ListFeaturesRequest) (*featureFilter, pageCursor, error) {
+	var br badRequest
+	br.checkPoint("bottomLeftCorner", req.BottomLeftCorner)
+	br.checkPoint("topRightCorner", req.TopRightCorner)
+	filter := newFeatureFilter(&br, "filter", req.Filter)
+	if req.PageSize < 0 {
+		br.add("page_size", "must not be negative, got %d", req.PageSize)
+	}
+	if req.MaxResults < 0 {
+		br.add("max_results", "must not be negative, got %d", req.MaxResults)
+	}
+	if _, ok := pb.SortOrder_name[int32(req.OrderBy)]; !ok {
+		br.add("order_by", "unknown sort order %d", req.OrderBy)
+	} else if req.OrderBy == pb.SortOrder_SORT_ORDER_DISTANCE {
+		br.checkPoint("reference_point", req.ReferencePoint)
+	}
+	cursor, ok := decodePageCursor(req.PageToken)
+	if !ok {
+		br.add("page_token", "is malformed")
+	} else if req.PageToken != "" && cursor.Fingerprint != requestFingerprint(req) {
+		br.add("page_token", "belongs to a different request; only page_size may change between pages")
+	}
+	return filter, cursor, br.err()
+}
+
+func validateCircle(circle *pb.Circle) error {
+	var br badRequest
+	br.checkPoint("center", circle.Center)
+	br.checkDistance("radius_m", circle.RadiusM)
+	return br.err()
+}
+
+func validateNearestRequest(req *pb.NearestRequest) error {
+	var br badRequest
+	br.checkPoint("point", req.Point)
+	if req.K <= 0 || req.K > maxNearestK {
+		br.add("k", "must be between 1 and %d, got %d", maxNearestK, req.K)
+	}
+	br.checkDistance("max_distance_m", req.MaxDistanceM)
+	return br.err()
+}
+
+// validateSearchRequest checks the query and limit and returns the distinct
+// query words
+func validateSearchRequest(req *pb.SearchRequest) ([]string, error) {
+	var br badRequest
+	words := tokenize(req.Query)
+	slices.Sort(words)
+	words = slices.Compact(words)
+	if len(req.Query) > maxQueryLength {
+		br.add("query", "must be at most %d bytes, got %d", maxQueryLength, len(req.Query))
+	} else if len(words) == 0 {
+		br.add("query", "must contain at least one word")
+	}
+	if req.Limit < 0 || req.Limit > maxSearchResults {
+		br.add("limit", "must be between 0 and %d, got %d", maxSearchResults, req.Limit)
+	}
+	return words, br.err()
+}
+
+func validateRouteNote(note *pb.RouteNote) error {
+	var br badRequest
+	br.checkPoint("location", note.Location)
+	return br.err()
+}
+
+// featureFields are the update_mask paths UpdateFeature accepts
+var featureFields = []string{"name", "location", "category", "tags", "description", "elevation_m"}
+
+func validateCreateFeatureRequest(req *pb.CreateFeatureRequest) error {
+	var br badRequest
+	if req.Feature == nil {
+		br.add("feature", "is required")
+		return br.err()
+	}
+	if req.Feature.Name == "" {
+		br.add("feature.name", "is required")
+	}
+	br.checkPoint("feature.location", req.Feature.Location)
+	for _, field := range featureFields {
+		br.checkFeatureField(field, req.Feature)
+	}
+	return br.err()
+}
+
+func validateUpdateFeatureRequest(req *pb.UpdateFeatureRequest) error {
+	var br badRequest
+	if req.Feature == nil {
+		br.add("feature", "is required")
+		return br.err()
+	}
+	if req.Feature.Id == "" {
+		br.add("feature.id", "is required")
+	}
+	paths := req.UpdateMask.GetPaths()
+	if len(paths) == 0 {
+		paths = featureFields
+	}
+	for i, path := range paths {
+		switch path {
+		case "name":
+			if req.Feature.Name == "" {
+				br.add("feature.name", "must not be empty")
+			}
+		case "location":
+			br.checkPoint("feature.location", req.Feature.Location)
+		default:
+			if !slices.Contains(featureFields, path) {
+				br.add(fmt.Sprintf("update_mask.paths[%d]", i), "unknown field %q, want one of %s", path, strings.Join(featureFields, ", "))
+				continue
+			}
+			br.checkFeatureField(path, req.Feature)
+		}
+	}
+	return br.err()
+}
+
+// checkFeatureField records violations for one of the metadata fields of a
+// feature being created or updated
+func (br *badRequest) checkFeatureField(field string, f *pb.Feature) {
+	switch field {
+	case "category":
+		if _, ok := pb.Category_name[int32(f.Category)]; !ok {
+			br.add("feature.category", "unknown category %d", f.Category)
+		}
+	case "tags":
+		if _, ok := f.Tags[""]; ok {
+			br.add("feature.tags", "keys must not be empty")
+		}
+	case "elevation_m":
+		if math.IsNaN(f.ElevationM) || math.IsInf(f.ElevationM, 0) {
+			br.add("feature.elevation_m", "must be a finite number of metres, got %v", f.ElevationM)
+		}
+	}
+}
+
+func validateDeleteFeatureRequest(req *pb.DeleteFeatureRequest) error {
+	var br badRequest
+	if req.Id == "" {
+		br.add("id", "is required")
+	}
+	return br.err()
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"math"
+	pb "routeguide/routeguide"
+	"slices"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// recvErr returns the error that ends a server stream
+func recvErr[T any](recv func() (T, error)) error {
+	for {
+		if _, err := recv(); err != nil {
+			return err
+		}
+	}
+}
+
+func TestMalformedRequests(t *testing.T) {
+	client := newTestClient(t)
+	valid := pt(40e7, -74e7)
+	rect := func(lo, hi *pb.Point) *pb.ListFeaturesRequest {
+		return &pb.ListFeaturesRequest{BottomLeftCorner: lo, TopRightCorner: hi}
+	}
+
+	tests := []struct {
+		name   string
+		call   func(context.Context) error
+		fields []string
+	}{
+		{"GetFeature latitude out of range", func(ctx context.Context) error {
+			_, err := client.GetFeature(ctx, pt(90e7+1, 0))
+			return err
+		}, []string{"latitude"}},
+		{"GetFeature both coordinates out of range", func(ctx context.Context) error {
+			_, err := client.GetFeature(ctx, pt(-90e7-1, 180e7+1))
+			return err
+		}, []string{"latitude", "longitude"}},
+
+		{"ListFeatures nil corners", func(ctx context.Context) error {
+			stream, err := client.ListFeatures(ctx, rect(nil, nil))
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"bottomLeftCorner", "topRightCorner"}},
+		{"ListFeatures corner out of range", func(ctx context.Context) error {
+			stream, err := client.ListFeatures(ctx, rect(pt(0, math.MinInt32), valid))
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"bottomLeftCorner.longitude"}},
+		{"ListFeatures bad filter and paging", func(ctx context.Context) error {
+			req := rect(valid, valid)
+			req.Filter = &pb.FeatureFilter{
+				Categories: []pb.Category{99},
+				Tags:       []*pb.TagPredicate{{Key: "", Op: 99}},
+			}
+			req.PageSize = -1
+			req.MaxResults = -1
+			req.PageToken = "not a token"
+			stream, err := client.ListFeatures(ctx, req)
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"filter.categories[0]", "filter.tags[0].key", "filter.tags[0].op", "page_size", "max_results", "page_token"}},
+		{"ListFeatures distance order without reference point", func(ctx context.Context) error {
+			req := rect(valid, valid)
+			req.OrderBy = pb.SortOrder_SORT_ORDER_DISTANCE
+			stream, err := client.ListFeatures(ctx, req)
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"reference_point"}},
+
+		{"ListFeaturesInRadius nil center and negative radius", func(ctx context.Context) error {
+			stream, err := client.ListFeaturesInRadius(ctx, &pb.Circle{RadiusM: -1})
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"center", "radius_m"}},
+		{"ListFeaturesInRadius NaN radius", func(ctx context.Context) error {
+			stream, err := client.ListFeaturesInRadius(ctx, &pb.Circle{Center: valid, RadiusM: math.NaN()})
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"radius_m"}},
+
+		{"ListFeaturesInPolygon too few points", func(ctx context.Context) error {
+			stream, err := client.ListFeaturesInPolygon(ctx, &pb.Polygon{Points: []*pb.Point{pt(0, 0), pt(1, 1)}})
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"points"}},
+		{"ListFeaturesInPolygon vertex out of range", func(ctx context.Context) error {
+			stream, err := client.ListFeaturesInPolygon(ctx, &pb.Polygon{Points: []*pb.Point{pt(0, 0), pt(91e7, 1), pt(1, 1), pt(0, 1)}})
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"points[1].latitude"}},
+
+		{"NearestFeatures k is 0", func(ctx context.Context) error {
+			stream, err := client.NearestFeatures(ctx, &pb.NearestRequest{Point: valid, K: 0})
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"k"}},
+		{"NearestFeatures k above 1000", func(ctx context.Context) error {
+			stream, err := client.NearestFeatures(ctx, &pb.NearestRequest{Point: valid, K: maxNearestK + 1})
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"k"}},
+		{"NearestFeatures nil point and negative distance", func(ctx context.Context) error {
+			stream, err := client.NearestFeatures(ctx, &pb.NearestRequest{K: 1, MaxDistanceM: -1})
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"point", "max_distance_m"}},
+
+		{"SearchFeatures empty query and limit out of range", func(ctx context.Context) error {
+			stream, err := client.SearchFeatures(ctx, &pb.SearchRequest{Query: " ", Limit: maxSearchResults + 1})
+			if err != nil {
+				return err
+			}
+			return recvErr(stream.Recv)
+		}, []string{"query", "limit"}},
+
+		{"RecordRoute point out of range", func(ctx context.Context) error {
+			stream, err := client.RecordRoute(ctx)
+			if err != nil {
+				return err
+			}
+			stream.Send(valid)
+			stream.Send(pt(91e7, 0))
+			_, err = stream.CloseAndRecv()
+			return err
+		}, []string{"points[1].latitude"}},
+
+		{"RouteChat nil location", func(ctx context.Context) error {
+			stream, err := client.RouteChat(ctx)
+			if err != nil {
+				return err
+			}
+			stream.Send(&pb.RouteNote{Message: "lost"})
+			return recvErr(stream.Recv)
+		}, []string{"location"}},
+		{"RouteChat location out of range", func(ctx context.Context) error {
+			stream, err := client.RouteChat(ctx)
+			if err != nil {
+				return err
+			}
+			stream.Send(&pb.RouteNote{Location: pt(0, 181e7), Message: "lost"})
+			return recvErr(stream.Recv)
+		}, []string{"location.longitude"}},
+
+		{"CreateFeature nil feature", func(ctx context.Context) error {
+			_, err := client.CreateFeature(ctx, &pb.CreateFeatureRequest{})
+			return err
+		}, []string{"feature"}},
+		{"CreateFeature missing name and location", func(ctx context.Context) error {
+			_, err := client.CreateFeature(ctx, &pb.CreateFeatureRequest{Feature: &pb.Feature{ElevationM: math.Inf(1)}})
+			return err
+		}, []string{"feature.name", "feature.location", "feature.elevation_m"}},
+
+		{"UpdateFeature missing id and unknown mask path", func(ctx context.Context) error {
+			_, err := client.UpdateFeature(ctx, &pb.UpdateFeatureRequest{
+				Feature:    &pb.Feature{Name: "x"},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id", "location"}},
+			})
+			return err
+		}, []string{"feature.id", "update_mask.paths[0]", "feature.location"}},
+
+		{"DeleteFeature missing id", func(ctx context.Context) error {
+			_, err := client.DeleteFeature(ctx, &pb.DeleteFeatureRequest{})
+			return err
+		}, []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(t.Context())
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("got %v, want InvalidArgument", err)
+			}
+			var fields []string
+			for _, detail := range st.Details() {
+				if br, ok := detail.(*errdetails.BadRequest); ok {
+					for _, v := range br.FieldViolations {
+						fields = append(fields, v.Field)
+					}
+				}
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("BadRequest fields = %q, want %q", fields, tt.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	pb "routeguide/routeguide"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Files kept in the note store directory
+const (
+	walLogFile      = "notes.log"
+	walSnapshotFile = "notes.snapshot"
+)
+
+// Record types. Every record is framed as a little-endian uint32 payload
+// length, a CRC-32C of the payload, then the payload: one type byte and its body.
+const (
+	recordNote     byte = 1 // body is a marshaled pb.RouteNote
+	recordSequence byte = 2 // body is the uint64 last assigned sequence number
+)
+
+const recordHeaderSize = 8
+
+// maxRecordSize guards recovery against reading a garbage length as a huge allocation
+const maxRecordSize = 4 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errBadRecord reports a truncated or corrupted record
+var errBadRecord = errors.New("truncated or corrupted record")
+
+// fsyncPolicy controls when appended notes are forced to disk
+type fsyncPolicy int
+
+const (
+	fsyncAlways   fsyncPolicy = iota // fsync after every note; nothing acknowledged is lost
+	fsyncInterval                    // fsync periodically; a crash loses at most one interval
+	fsyncNever                       // leave flushing to the operating system
+)
+
+func parseFsyncPolicy(s string) (fsyncPolicy, error) {
+	switch s {
+	case "always":
+		return fsyncAlways, nil
+	case "interval":
+		return fsyncInterval, nil
+	case "never":
+		return fsyncNever, nil
+	}
+	return 0, fmt.Errorf("unknown fsync policy %q, want always, interval or never", s)
+}
+
+// walOptions configures a walNoteStore
+type walOptions struct {
+	fsync         fsyncPolicy
+	fsyncInterval time.Duration // used with fsyncInterval
+	snapshotEvery int           // appends between snapshots; 0 never snapshots
+}
+
+// walNoteStore is a NoteStore that survives restarts. Every note is appended
+// to a log file before it is applied to an in-memory store. Periodically the
+// live notes are written to a snapshot file and the log is truncated, so the
+// log does not keep notes the retention policy has already dropped.
+type walNoteStore struct {
+	*memoryNoteStore
+	dir           string
+	opts          walOptions
+	sinceSnapshot int // appends since the last snapshot
+
+	fileMu  sync.Mutex // guards log, logSize and failed against the background syncer
+	log     logFile
+	logSize int64 // bytes of complete records in log
+	failed  error // set when a failed append could not be undone; later appends are refused
+
+	snapshotting chan struct{} // holds a token while a snapshot is being written
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// logFile is the part of *os.File the store writes its log through
+type logFile interface {
+	io.Writer
+	io.ReaderAt
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// openWALNoteStore recovers the notes stored in dir, creating it if needed.
+// A log whose tail was torn by a crash is truncated to its last complete
+// record; a damaged snapshot is reported as an error.
+func openWALNoteStore(dir string, retention retentionPolicy, opts walOptions) (*walNoteStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	w := &walNoteStore{
+		memoryNoteStore: newMemoryNoteStore(retention),
+		dir:             dir,
+		opts:            opts,
+		snapshotting:    make(chan struct{}, 1),
+	}
+
+	snapshotSequence, err := w.loadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.replayLog(snapshotSequence); err != nil {
+		return nil, err
+	}
+	// Notes that expired while the server was down must not be served
+	// until the janitor's first pass
+	if retention.maxAge > 0 {
+		if n := w.expire(time.Now().Add(-retention.maxAge), math.MaxInt); n > 0 {
+			notesEvicted.Add("expired", int64(n))
+			slog.Info("expired recovered route notes", "count", n, "max_age", retention.maxAge)
+		}
+	}
+	slog.Info("recovered route notes", "count", w.noteCount, "dir", w.dir)
+
+	if opts.fsync == fsyncInterval && opts.fsyncInterval > 0 {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.syncLoop()
+	}
+	return w, nil
+}
+
+// loadSnapshot restores the notes in the snapshot file, if there is one,
+// and returns the last sequence number it covers
+func (w *walNoteStore) loadSnapshot() (uint64, error) {
+	path := filepath.Join(w.dir, walSnapshotFile)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var covered uint64
+	_, err = readRecords(bufio.NewReader(f), func(typ byte, body []byte) error {
+		switch typ {
+		case recordSequence:
+			covered = binary.LittleEndian.Uint64(body)
+			w.lastSequence = max(w.lastSequence, covered)
+			return nil
+		default:
+			note, err := decodeNote(body)
+			if err != nil {
+				return err
+			}
+			w.insert(serialize(note.Location), note)
+			return nil
+		}
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return covered, nil
+}
+
+// replayLog applies the log's notes newer than the snapshot, truncates any
+// damaged tail and leaves the log open for appending
+func (w *walNoteStore) replayLog(snapshotSequence uint64) error {
+	path := filepath.Join(w.dir, walLogFile)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	replayed := 0
+	valid, err := readRecords(bufio.NewReader(f), func(typ byte, body []byte) error {
+		if typ != recordNote {
+			return errBadRecord
+		}
+		note, err := decodeNote(body)
+		if err != nil {
+			return err
+		}
+		// A crash between writing a snapshot and truncating the log
+		// leaves notes in both; the snapshot copy wins
+		if note.Sequence <= snapshotSequence {
+			return nil
+		}
+		w.insert(serialize(note.Location), note)
+		replayed++
+		return nil
+	})
+	if errors.Is(err, errBadRecord) {
+		slog.Warn("discarding damaged note log tail", "path", path, "offset", valid)
+		if err := f.Truncate(valid); err != nil {
+			f.Close()
+			return err
+		}
+		err = nil
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	w.log = f
+	w.logSize = valid
+	w.sinceSnapshot = replayed
+	return nil
+}
+
+// decodeNote unmarshals the body of a note record
+func decodeNote(body []byte) (*pb.RouteNote, error) {
+	note := &pb.RouteNote{}
+	if err := proto.Unmarshal(body, note); err != nil || note.Location == nil {
+		return nil, errBadRecord
+	}
+	return note, nil
+}
+
+func (w *walNoteStore) append(key string, note *pb.RouteNote) error {
+	// The note is logged exactly as it will be served, and only applied
+	// in memory once the log write has succeeded
+	w.stamp(note)
+	body, err := proto.Marshal(note)
+	if err != nil {
+		return err
+	}
+	if err := w.writeNote(body); err != nil {
+		return err
+	}
+	w.insert(key, note)
+
+	w.sinceSnapshot++
+	if w.opts.snapshotEvery > 0 && w.sinceSnapshot >= w.opts.snapshotEvery {
+		// The caller holds the server's mu, so only the copy of the live
+		// notes is taken here; writing them out happens in the background
+		select {
+		case w.snapshotting <- struct{}{}:
+			notes, sequence, cut := w.snapshotState()
+			go func() {
+				defer func() { <-w.snapshotting }()
+				if err := w.writeSnapshot(notes, sequence, cut); err != nil {
+					slog.Error("note snapshot failed, keeping the full log", "err", err)
+				}
+			}()
+		default:
+			// The previous snapshot is still being written
+		}
+	}
+	return nil
+}
+
+// writeNote logs one note record. If the write or fsync fails, the log is
+// truncated back to its last complete record, so a torn record cannot make
+// recovery discard the notes appended after it; if even that fails, the
+// store refuses every later append.
+func (w *walNoteStore) writeNote(body []byte) error {
+	w.fileMu.Lock()
+	defer w.fileMu.Unlock()
+	if w.failed != nil {
+		return fmt.Errorf("note log unusable after an earlier failure: %w", w.failed)
+	}
+	err := writeRecord(w.log, recordNote, body)
+	if err == nil && w.opts.fsync == fsyncAlways {
+		err = w.log.Sync()
+	}
+	if err != nil {
+		if terr := w.log.Truncate(w.logSize); terr != nil {
+			w.failed = terr
+			slog.Error("undoing a failed note log write failed, refusing further notes", "err", terr)
+		}
+		return err
+	}
+	w.logSize += recordHeaderSize + 1 + int64(len(body))
+	return nil
+}
+
+// snapshot writes every live note to a new snapshot file and waits for it
+func (w *walNoteStore) snapshot() error {
+	w.snapshotting <- struct{}{}
+	defer func() { <-w.snapshotting }()
+	return w.writeSnapshot(w.snapshotState())
+}
+
+// snapshotState returns what a snapshot taken now covers: the live notes,
+// the last sequence number assigned and the length of the log so far.
+// Like append, it must not run concurrently with other store methods.
+func (w *walNoteStore) snapshotState() (notes []*pb.RouteNote, sequence uint64, cut int64) {
+	w.sinceSnapshot = 0
+	w.fileMu.Lock()
+	cut = w.logSize
+	w.fileMu.Unlock()
+	return w.all(), w.lastSequence, cut
+}
+
+// writeSnapshot writes notes to a new snapshot file, swaps it in and drops
+// the first cut bytes of the log, which the snapshot now covers. Stored
+// notes are never modified, so notes may be read while appends continue.
+func (w *walNoteStore) writeSnapshot(notes []*pb.RouteNote, lastSequence uint64, cut int64) error {
+	path := filepath.Join(w.dir, walSnapshotFile)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	buf := bufio.NewWriter(f)
+	var sequence [8]byte
+	binary.LittleEndian.PutUint64(sequence[:], lastSequence)
+	err = writeRecord(buf, recordSequence, sequence[:])
+	for _, note := range notes {
+		if err != nil {
+			break
+		}
+		var body []byte
+		if body, err = proto.Marshal(note); err == nil {
+			err = writeRecord(buf, recordNote, body)
+		}
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	if err := syncDir(w.dir); err != nil {
+		return err
+	}
+	return w.dropLogPrefix(cut)
+}
+
+// dropLogPrefix removes the first cut bytes of the log. Notes appended
+// while the snapshot was written are copied to a new log that replaces the
+// old one; a crash before the rename leaves the old log, whose notes the
+// snapshot already holds are skipped on recovery.
+func (w *walNoteStore) dropLogPrefix(cut int64) error {
+	w.fileMu.Lock()
+	defer w.fileMu.Unlock()
+	if w.failed != nil {
+		return w.failed
+	}
+	if cut == w.logSize {
+		if err := w.log.Truncate(0); err != nil {
+			return err
+		}
+		w.logSize = 0
+		return w.log.Sync()
+	}
+
+	tail := make([]byte, w.logSize-cut)
+	if _, err := w.log.ReadAt(tail, cut); err != nil {
+		return err
+	}
+	path := filepath.Join(w.dir, walLogFile)
+	f, err := os.OpenFile(path+".tmp", os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(tail)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(path + ".tmp")
+		return err
+	}
+	// The old log is gone from the directory, so appends must move to the
+	// new one whatever happens next
+	w.log.Close()
+	w.log = f
+	w.logSize = int64(len(tail))
+	return syncDir(w.dir)
+}
+
+// syncLoop fsyncs the log every fsyncInterval until the store is closed
+func (w *walNoteStore) syncLoop() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.fsyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.fileMu.Lock()
+			if err := w.log.Sync(); err != nil {
+				slog.Error("note log fsync failed", "err", err)
+			}
+			w.fileMu.Unlock()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *walNoteStore) close() error {
+	// Let a snapshot being written finish
+	w.snapshotting <- struct{}{}
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+	w.fileMu.Lock()
+	defer w.fileMu.Unlock()
+	if err := w.log.Sync(); err != nil {
+		w.log.Close()
+		return err
+	}
+	return w.log.Close()
+}
+
+// writeRecord frames and writes one record with a single Write call,
+// so a crash can tear at most the record being written
+func writeRecord(out io.Writer, typ byte, body []byte) error {
+	record := make([]byte, recordHeaderSize+1+len(body))
+	payload := record[recordHeaderSize:]
+	payload[0] = typ
+	copy(payload[1:], body)
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(payload, crcTable))
+	_, err := out.Write(record)
+	return err
+}
+
+// readRecords calls fn for each record in r until EOF. It returns the offset
+// just past the last good record, with errBadRecord if the data after it is
+// incomplete or fails its checksum.
+func readRecords(r io.Reader, fn func(typ byte, body []byte) error) (int64, error) {
+	var offset int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return offset, nil
+		} else if err != nil {
+			return offset, errBadRecord
+		}
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size == 0 || size > maxRecordSize {
+			return offset, errBadRecord
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return offset, errBadRecord
+		}
+		if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+			return offset, errBadRecord
+		}
+		if payload[0] == recordSequence && len(payload) != 9 {
+			return offset, errBadRecord
+		}
+		if err := fn(payload[0], payload[1:]); err != nil {
+			return offset, err
+		}
+		offset += recordHeaderSize + int64(size)
+	}
+}
+
+// syncDir fsyncs a directory so a rename inside it is durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	pb "routeguide/routeguide"
+	"slices"
+	"testing"
+	"time"
+)
+
+func openTestWAL(t *testing.T, dir string) *walNoteStore {
+	t.Helper()
+	w, err := openWALNoteStore(dir, retentionPolicy{}, walOptions{fsync: fsyncAlways})
+	if err != nil {
+		t.Fatalf("openWALNoteStore: %v", err)
+	}
+	return w
+}
+
+// appendNote posts a note and returns the sequence number it was given
+func appendNote(t *testing.T, w *walNoteStore, message string) uint64 {
+	t.Helper()
+	note := &pb.RouteNote{Location: pt(1, 2), Message: message}
+	if err := w.append(serialize(note.Location), note); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	return note.Sequence
+}
+
+func closeWAL(t *testing.T, w *walNoteStore) {
+	t.Helper()
+	if err := w.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+// storedSequences lists the sequence numbers of every stored note, oldest first
+func storedSequences(w *walNoteStore) []uint64 {
+	var sequences []uint64
+	for _, note := range w.all() {
+		sequences = append(sequences, note.Sequence)
+	}
+	return sequences
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestWALRecoversDamagedTail(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(log []byte, lastRecord int) []byte
+	}{
+		{"truncated mid-header", func(log []byte, lastRecord int) []byte {
+			return log[:lastRecord+recordHeaderSize/2]
+		}},
+		{"truncated mid-payload", func(log []byte, lastRecord int) []byte {
+			return log[:len(log)-3]
+		}},
+		{"flipped CRC byte", func(log []byte, lastRecord int) []byte {
+			log[lastRecord+5] ^= 0xff
+			return log
+		}},
+		{"flipped payload byte", func(log []byte, lastRecord int) []byte {
+			log[len(log)-1] ^= 0xff
+			return log
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, walLogFile)
+			w := openTestWAL(t, dir)
+			appendNote(t, w, "first")
+			appendNote(t, w, "second")
+			lastRecord := fileSize(t, path)
+			appendNote(t, w, "third")
+			closeWAL(t, w)
+
+			log, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.damage(log, int(lastRecord)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			w = openTestWAL(t, dir)
+			if got, want := storedSequences(w), []uint64{1, 2}; !slices.Equal(got, want) {
+				t.Errorf("recovered sequences %v, want %v", got, want)
+			}
+			if got := fileSize(t, path); got != lastRecord {
+				t.Errorf("log is %d bytes after recovery, want it truncated to %d", got, lastRecord)
+			}
+
+			// Records appended after the truncation must be readable again
+			appendNote(t, w, "after recovery")
+			closeWAL(t, w)
+			w = openTestWAL(t, dir)
+			defer closeWAL(t, w)
+			if got, want := storedSequences(w), []uint64{1, 2, 3}; !slices.Equal(got, want) {
+				t.Errorf("sequences after reopening %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// A crash after the snapshot is renamed into place but before the log is
+// truncated leaves the same notes in both files
+func TestWALCrashBetweenSnapshotAndTruncate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, walLogFile)
+	w := openTestWAL(t, dir)
+	for i := range 3 {
+		appendNote(t, w, fmt.Sprint("before snapshot ", i))
+	}
+	untruncated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.snapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	appendNote(t, w, "after snapshot")
+	closeWAL(t, w)
+
+	// Put back the log as it was before the truncate, followed by what was
+	// appended afterwards
+	tail, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(untruncated, tail...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w = openTestWAL(t, dir)
+	defer closeWAL(t, w)
+	if got, want := storedSequences(w), []uint64{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("recovered sequences %v, want %v with no duplicates", got, want)
+	}
+	if got := len(w.history(serialize(pt(1, 2)), 0)); got != 4 {
+		t.Errorf("history has %d notes, want 4", got)
+	}
+}
+
+func TestWALSequenceIncreasesAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	w := openTestWAL(t, dir)
+	appendNote(t, w, "one")
+	appendNote(t, w, "two")
+	closeWAL(t, w)
+
+	w = openTestWAL(t, dir)
+	if got := appendNote(t, w, "three"); got != 3 {
+		t.Errorf("first note after reopening got sequence %d, want 3", got)
+	}
+
+	// Once every note has expired only the snapshot's sequence record is
+	// left to carry the last sequence across a restart
+	if n := w.expire(time.Now().Add(time.Hour), 100); n != 3 {
+		t.Fatalf("expired %d notes, want 3", n)
+	}
+	if err := w.snapshot(); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	closeWAL(t, w)
+
+	w = openTestWAL(t, dir)
+	defer closeWAL(t, w)
+	if got := storedSequences(w); len(got) != 0 {
+		t.Errorf("expired notes came back: %v", got)
+	}
+	if got := appendNote(t, w, "four"); got != 4 {
+		t.Errorf("first note after reopening an empty store got sequence %d, want 4", got)
+	}
+}
+
+// tornFile writes only part of the next record it is given, then fails
+type tornFile struct {
+	logFile
+	tear bool
+}
+
+func (f *tornFile) Write(p []byte) (int, error) {
+	if f.tear {
+		f.tear = false
+		n, _ := f.logFile.Write(p[:len(p)/2])
+		return n, errors.New("disk full")
+	}
+	return f.logFile.Write(p)
+}
+
+// A failed append must not leave a torn record that recovery would stop
+// at, discarding the notes appended after it
+func TestWALFailedAppendKeepsLaterNotes(t *testing.T) {
+	dir := t.TempDir()
+	w := openTestWAL(t, dir)
+	appendNote(t, w, "before")
+	torn := &tornFile{logFile: w.log, tear: true}
+	w.log = torn
+	if err := w.append(serialize(pt(1, 2)), &pb.RouteNote{Location: pt(1, 2), Message: "torn"}); err == nil {
+		t.Fatal("append succeeded despite the failed write")
+	}
+	appendNote(t, w, "after")
+	closeWAL(t, w)
+
+	w = openTestWAL(t, dir)
+	defer closeWAL(t, w)
+	var messages []string
+	for _, note := range w.all() {
+		messages = append(messages, note.Message)
+	}
+	if want := []string{"before", "after"}; !slices.Equal(messages, want) {
+		t.Errorf("recovered %q, want %q", messages, want)
+	}
+}
+
+// failingFile fails every write and truncate
+type failingFile struct{ logFile }
+
+func (failingFile) Write([]byte) (int, error) { return 0, errors.New("I/O error") }
+func (failingFile) Truncate(int64) error      { return errors.New("I/O error") }
+
+func TestWALRefusesAppendsAfterUndoFails(t *testing.T) {
+	w := openTestWAL(t, t.TempDir())
+	file := w.log
+	w.log = failingFile{file}
+	note := func() error {
+		return w.append(serialize(pt(1, 2)), &pb.RouteNote{Location: pt(1, 2), Message: "lost"})
+	}
+	if err := note(); err == nil {
+		t.Fatal("append succeeded despite the failed write")
+	}
+	w.log = file
+	if err := note(); err == nil {
+		t.Error("append succeeded after a torn record could not be removed")
+	}
+	closeWAL(t, w)
+}
+
+// Notes appended while a snapshot is being written stay in the log
+func TestWALSnapshotKeepsLaterAppends(t *testing.T) {
+	dir := t.TempDir()
+	w := openTestWAL(t, dir)
+	appendNote(t, w, "one")
+	appendNote(t, w, "two")
+	notes, sequence, cut := w.snapshotState()
+	appendNote(t, w, "three")
+	if err := w.writeSnapshot(notes, sequence, cut); err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+	appendNote(t, w, "four")
+	closeWAL(t, w)
+
+	w = openTestWAL(t, dir)
+	defer closeWAL(t, w)
+	if got, want := storedSequences(w), []uint64{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("recovered sequences %v, want %v", got, want)
+	}
+	if got := len(w.history(serialize(pt(1, 2)), 0)); got != 4 {
+		t.Errorf("history has %d notes, want 4 with no duplicates", got)
+	}
+}
+
+// Snapshots taken every few appends run in the background without losing
+// or duplicating notes
+func TestWALBackgroundSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	w, err := openWALNoteStore(dir, retentionPolicy{}, walOptions{fsync: fsyncAlways, snapshotEvery: 3})
+	if err != nil {
+		t.Fatalf("openWALNoteStore: %v", err)
+	}
+	var want []uint64
+	for i := range 50 {
+		want = append(want, appendNote(t, w, fmt.Sprint("note ", i)))
+	}
+	closeWAL(t, w)
+	if _, err := os.Stat(filepath.Join(dir, walSnapshotFile)); err != nil {
+		t.Fatalf("no snapshot was written: %v", err)
+	}
+
+	w = openTestWAL(t, dir)
+	defer closeWAL(t, w)
+	if got := storedSequences(w); !slices.Equal(got, want) {
+		t.Errorf("recovered sequences %v, want %v", got, want)
+	}
+}
+
+func TestWALDropsNotesExpiredWhileClosed(t *testing.T) {
+	dir := t.TempDir()
+	w := openTestWAL(t, dir)
+	appendNote(t, w, "old")
+	appendNote(t, w, "older")
+	closeWAL(t, w)
+
+	time.Sleep(10 * time.Millisecond)
+	w, err := openWALNoteStore(dir, retentionPolicy{maxAge: 5 * time.Millisecond}, walOptions{fsync: fsyncAlways})
+	if err != nil {
+		t.Fatalf("openWALNoteStore: %v", err)
+	}
+	defer closeWAL(t, w)
+	if got := storedSequences(w); len(got) != 0 {
+		t.Errorf("notes past their max age were recovered: %v", got)
+	}
+	if got := len(w.history(serialize(pt(1, 2)), 0)); got != 0 {
+		t.Errorf("history has %d expired notes", got)
+	}
+}